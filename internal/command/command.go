@@ -101,8 +101,8 @@ func (c Command) AddToParent(parent *cobra.Command) {
 			defer sentry.Recover()
 		}
 
-		// initialize file loader used in commands
-		loader := &afero.Afero{Fs: afero.NewOsFs()}
+		// initialize file loader used in commands, which keeps the CLI sections of flow.json when flowkit saves it
+		loader := util.NewConfigReaderWriter(&afero.Afero{Fs: afero.NewOsFs()}, Flags.ConfigPaths)
 
 		// if we receive a config error that isn't missing config we should handle it
 		state, confErr := flowkit.Load(Flags.ConfigPaths, loader)
@@ -113,7 +113,7 @@ func (c Command) AddToParent(parent *cobra.Command) {
 		network, err := resolveHost(state, Flags.Host, Flags.HostNetworkKey, Flags.Network)
 		handleError("Host Error", err)
 
-		clientGateway, err := createGateway(*network)
+		clientGateway, err := CreateGateway(*network)
 		handleError("Gateway Error", err)
 
 		logger := createLogger(Flags.Log, Flags.Format)
@@ -169,8 +169,8 @@ func (c Command) AddToParent(parent *cobra.Command) {
 	parent.AddCommand(c.Cmd)
 }
 
-// createGateway creates a gateway to be used, defaults to grpc but can support others.
-func createGateway(network config.Network) (gateway.Gateway, error) {
+// CreateGateway creates a gateway to be used, defaults to grpc but can support others.
+func CreateGateway(network config.Network) (gateway.Gateway, error) {
 	// create secure grpc client if hostNetworkKey provided
 	if network.Key != "" {
 		return gateway.NewSecureGrpcGateway(network)
@@ -20,7 +20,9 @@ package dependencymanager
 
 import (
 	"fmt"
+	"strings"
 
+	flowsdk "github.com/onflow/flow-go-sdk"
 	"github.com/spf13/cobra"
 
 	"github.com/onflow/flowkit"
@@ -30,7 +32,11 @@ import (
 )
 
 type addFlagsCollection struct {
-	name string `default:"" flag:"name" info:"Name of the dependency"`
+	name       string   `default:"" flag:"name" info:"Name of the dependency"`
+	OnConflict string   `default:"fail" flag:"on-conflict" info:"How to resolve dependencies with the same name but a different source: fail, keep, replace or rename"`
+	Aliases    []string `default:"" flag:"alias" info:"Address of the contract on another network in the format network=address, can be repeated. Not added to the contracts it imports"`
+	Offline    bool     `default:"false" flag:"offline" info:"Install from the local dependency cache without accessing the network"`
+	DryRun     bool     `default:"false" flag:"dry-run" info:"Report the files and flow.json entries the installation would change without writing them"`
 }
 
 var addFlags = addFlagsCollection{}
@@ -39,7 +45,7 @@ var addCommand = &command.Command{
 	Cmd: &cobra.Command{
 		Use:     "add <source string>",
 		Short:   "Add a single contract and its dependencies.",
-		Example: "flow dependencies add testnet://0afe396ebc8eee65.FlowToken",
+		Example: "flow dependencies add testnet://0afe396ebc8eee65.FlowToken\nflow dependencies add git+https://github.com/onflow/flow-ft.git#master:contracts/FungibleToken.cdc\nflow dependencies add file://../shared/Contract.cdc",
 		Args:    cobra.ExactArgs(1),
 	},
 	Flags: &addFlags,
@@ -48,7 +54,7 @@ var addCommand = &command.Command{
 
 func add(
 	args []string,
-	_ command.GlobalFlags,
+	globalFlags command.GlobalFlags,
 	logger output.Logger,
 	flow flowkit.Services,
 	state *flowkit.State,
@@ -57,20 +63,53 @@ func add(
 
 	dep := args[0]
 
-	installer, err := NewDependencyInstaller(logger, state)
+	installer, err := NewDependencyInstaller(logger, state, flow)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Error: %v", err))
 		return nil, err
 	}
+	installer.ConfigPaths = globalFlags.ConfigPaths
+	installer.OnConflict = addFlags.OnConflict
+	installer.Offline = addFlags.Offline
+	installer.DryRun = addFlags.DryRun
+
+	installer.Aliases, err = parseAliases(addFlags.Aliases)
+	if err != nil {
+		return nil, err
+	}
 
 	if err := installer.Add(dep, addFlags.name); err != nil {
 		logger.Error(fmt.Sprintf("Error: %v", err))
 		return nil, err
 	}
 
+	if installer.DryRun {
+		return &planResult{plan: installer.Plan()}, nil
+	}
+
 	logger.Info("✅  Dependency installation complete. Check your flow.json")
 	logger.Info("Ensure you add any required dependencies to your 'deployments' section. This can be done using the 'flow config add deployment' command.")
 	logger.Info("Note: Core contracts do not need to be added to deployments. For reference, see this URL: https://github.com/onflow/flow-core-contracts")
 
 	return nil, nil
 }
+
+// parseAliases parses aliases provided in the format network=address.
+func parseAliases(values []string) (map[string]flowsdk.Address, error) {
+	aliases := make(map[string]flowsdk.Address, len(values))
+
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+
+		network, address, found := strings.Cut(value, "=")
+		if !found || network == "" || address == "" {
+			return nil, fmt.Errorf("invalid alias %s, use the format network=address", value)
+		}
+
+		aliases[network] = flowsdk.HexToAddress(address)
+	}
+
+	return aliases, nil
+}
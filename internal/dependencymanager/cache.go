@@ -0,0 +1,111 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dependencymanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	flowsdk "github.com/onflow/flow-go-sdk"
+	"github.com/spf13/afero"
+
+	"github.com/onflow/flow-cli/internal/settings"
+)
+
+const latestCacheEntry = "latest"
+
+// Cache is a content-addressed store of fetched contract code shared between projects.
+//
+// Contracts are stored as <root>/<network>/<address>/<contract>/<hash>.cdc, with a "latest"
+// file pointing to the hash of the most recently fetched version.
+type Cache struct {
+	fs   afero.Afero
+	root string
+}
+
+// NewCache creates a cache rooted at the provided directory.
+func NewCache(fs afero.Fs, root string) *Cache {
+	return &Cache{
+		fs:   afero.Afero{Fs: fs},
+		root: root,
+	}
+}
+
+// DefaultCache creates a cache in the CLI settings directory.
+func DefaultCache() *Cache {
+	return NewCache(afero.NewOsFs(), filepath.Join(settings.FileDir(), "dependencies"))
+}
+
+// Put stores the contract code and marks it as the latest fetched version, returning its hash.
+func (c *Cache) Put(networkName string, address flowsdk.Address, contractName string, code []byte) (string, error) {
+	dir := c.dir(networkName, address, contractName)
+	if err := c.fs.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("error creating cache directory: %w", err)
+	}
+
+	hash := codeHash(code)
+	if err := c.fs.WriteFile(filepath.Join(dir, hash+".cdc"), code, 0644); err != nil {
+		return "", fmt.Errorf("error writing cache entry: %w", err)
+	}
+
+	if err := c.fs.WriteFile(filepath.Join(dir, latestCacheEntry), []byte(hash), 0644); err != nil {
+		return "", fmt.Errorf("error writing cache entry: %w", err)
+	}
+
+	return hash, nil
+}
+
+// Get returns the cached contract code with the provided hash, or the latest fetched version if the hash is empty.
+//
+// The code is verified against its hash so a corrupted entry is never returned.
+func (c *Cache) Get(networkName string, address flowsdk.Address, contractName, hash string) ([]byte, error) {
+	dir := c.dir(networkName, address, contractName)
+
+	if hash == "" {
+		latest, err := c.fs.ReadFile(filepath.Join(dir, latestCacheEntry))
+		if err != nil {
+			return nil, fmt.Errorf("no cached version found")
+		}
+		hash = strings.TrimSpace(string(latest))
+	}
+
+	code, err := c.fs.ReadFile(filepath.Join(dir, hash+".cdc"))
+	if err != nil {
+		return nil, fmt.Errorf("version %s is not cached", hash)
+	}
+
+	if codeHash(code) != hash {
+		return nil, fmt.Errorf("cached version %s is corrupted", hash)
+	}
+
+	return code, nil
+}
+
+func (c *Cache) dir(networkName string, address flowsdk.Address, contractName string) string {
+	return filepath.Join(c.root, networkName, address.String(), contractName)
+}
+
+// codeHash computes the hex encoded sha256 hash of the code.
+func codeHash(code []byte) string {
+	hash := sha256.Sum256(code)
+	return hex.EncodeToString(hash[:])
+}
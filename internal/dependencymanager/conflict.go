@@ -0,0 +1,59 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dependencymanager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Strategies for resolving a dependency whose name is already used by a dependency with a different source.
+const (
+	// ConflictFail collects all conflicts and fails the installation without saving, this is the default.
+	ConflictFail = "fail"
+	// ConflictKeep keeps the existing dependency and skips the new one.
+	ConflictKeep = "keep"
+	// ConflictReplace replaces the existing dependency with the new one.
+	ConflictReplace = "replace"
+	// ConflictRename installs the new dependency under a name derived from its address.
+	ConflictRename = "rename"
+)
+
+// DependencyConflict is a dependency whose name is already used by a dependency with a different source.
+type DependencyConflict struct {
+	Name           string
+	ExistingSource string
+	NewSource      string
+}
+
+// ConflictError reports all the conflicts found during an installation.
+type ConflictError struct {
+	Conflicts []DependencyConflict
+}
+
+func (e *ConflictError) Error() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("found %d dependency conflicts:\n", len(e.Conflicts)))
+	for _, conflict := range e.Conflicts {
+		b.WriteString(fmt.Sprintf("  - %s: existing source %s, new source %s\n", conflict.Name, conflict.ExistingSource, conflict.NewSource))
+	}
+	b.WriteString("resolve the conflicts in flow.json or use the --on-conflict flag with keep, replace or rename")
+
+	return b.String()
+}
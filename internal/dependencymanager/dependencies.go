@@ -33,4 +33,8 @@ var Cmd = &cobra.Command{
 func init() {
 	addCommand.AddToParent(Cmd)
 	installCommand.AddToParent(Cmd)
+	outdatedCommand.AddToParent(Cmd)
+	updateCommand.AddToParent(Cmd)
+	removeCommand.AddToParent(Cmd)
+	treeCommand.AddToParent(Cmd)
 }
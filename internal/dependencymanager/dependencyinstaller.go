@@ -19,15 +19,21 @@
 package dependencymanager
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 
+	"golang.org/x/exp/slices"
+
+	"github.com/onflow/flow-cli/internal/command"
 	"github.com/onflow/flow-cli/internal/util"
 
+	"github.com/go-git/go-git/v5"
+
 	"github.com/onflow/flowkit/gateway"
 
 	"github.com/onflow/flowkit/project"
@@ -41,55 +47,95 @@ import (
 )
 
 type DependencyInstaller struct {
-	Gateways map[string]gateway.Gateway
-	Logger   output.Logger
-	State    *flowkit.State
-	Mutex    sync.Mutex
+	Gateways     map[string]gateway.Gateway
+	Logger       output.Logger
+	State        *flowkit.State
+	Mutex        sync.Mutex
+	Frozen       bool
+	Offline      bool
+	DryRun       bool
+	Force        bool
+	Cache        *Cache
+	OnConflict   string
+	Aliases      map[string]flowsdk.Address
+	networks     map[string]config.Network
+	gatewayMutex sync.Mutex
+	aliasedName  string
+	update       bool
+	conflicts    []DependencyConflict
+	repositories map[string]*git.Repository
+	externals    map[string]string
+	external     ExternalDependencies
+	lockfile     *Lockfile
+	blockHeights map[string]uint64
+	plan         *Plan
+	// prompt asks for a confirmation, util.GenericBoolPrompt is used if not set
+	prompt func(msg string) bool
+	// ConfigPaths are the configuration files of the project, the local flow.json is used if not set
+	ConfigPaths []string
+	// readOnly is set by commands that only inspect dependencies, fetched contracts are not cached
+	readOnly bool
 }
 
 // NewDependencyInstaller creates a new instance of DependencyInstaller
-func NewDependencyInstaller(logger output.Logger, state *flowkit.State) (*DependencyInstaller, error) {
-	emulatorGateway, err := gateway.NewGrpcGateway(config.EmulatorNetwork)
-	if err != nil {
-		return nil, fmt.Errorf("error creating emulator gateway: %v", err)
-	}
-
-	testnetGateway, err := gateway.NewGrpcGateway(config.TestnetNetwork)
-	if err != nil {
-		return nil, fmt.Errorf("error creating testnet gateway: %v", err)
+//
+// Gateways are created when a network is first used, for every network in the configuration falling back to the
+// default networks. The network selected for the command, including any host override, takes precedence over the
+// configured one with the same name.
+func NewDependencyInstaller(logger output.Logger, state *flowkit.State, flow flowkit.Services) (*DependencyInstaller, error) {
+	networks := make(map[string]config.Network)
+	for _, network := range config.DefaultNetworks {
+		networks[network.Name] = network
 	}
-
-	mainnetGateway, err := gateway.NewGrpcGateway(config.MainnetNetwork)
-	if err != nil {
-		return nil, fmt.Errorf("error creating mainnet gateway: %v", err)
+	for _, network := range *state.Networks() {
+		networks[network.Name] = network
 	}
 
-	gateways := map[string]gateway.Gateway{
-		config.EmulatorNetwork.Name: emulatorGateway,
-		config.TestnetNetwork.Name:  testnetGateway,
-		config.MainnetNetwork.Name:  mainnetGateway,
+	gateways := make(map[string]gateway.Gateway)
+	if flow != nil {
+		gateways[flow.Network().Name] = flow.Gateway()
 	}
 
 	return &DependencyInstaller{
 		Gateways: gateways,
+		networks: networks,
 		Logger:   logger,
 		State:    state,
+		Cache:    DefaultCache(),
 	}, nil
 }
 
 // Install processes all the dependencies in the state and installs them and any dependencies they have
 func (di *DependencyInstaller) Install() error {
+	if err := di.start(); err != nil {
+		return err
+	}
+
 	for _, dependency := range *di.State.Dependencies() {
 		if err := di.processDependency(dependency); err != nil {
 			di.Logger.Error(fmt.Sprintf("Error processing dependency: %v", err))
 			return err
 		}
 	}
-	return nil
+
+	if err := di.installExternal(nil); err != nil {
+		di.Logger.Error(fmt.Sprintf("Error processing dependency: %v", err))
+		return err
+	}
+
+	return di.save()
 }
 
 // Add processes a single dependency and installs it and any dependencies it has, as well as adding it to the state
 func (di *DependencyInstaller) Add(depSource, customName string) error {
+	if err := di.start(); err != nil {
+		return err
+	}
+
+	if isExternalSource(depSource) {
+		return di.addExternal(depSource, customName)
+	}
+
 	depNetwork, depAddress, depContractName, err := config.ParseSourceString(depSource)
 	if err != nil {
 		return fmt.Errorf("error parsing source: %w", err)
@@ -100,6 +146,7 @@ func (di *DependencyInstaller) Add(depSource, customName string) error {
 	if customName != "" {
 		name = customName
 	}
+	di.aliasedName = name
 
 	dep := config.Dependency{
 		Name: name,
@@ -110,81 +157,682 @@ func (di *DependencyInstaller) Add(depSource, customName string) error {
 		},
 	}
 
+	existing := make(map[string]bool)
+	for _, dependency := range *di.State.Dependencies() {
+		existing[dependency.Name] = true
+	}
+
 	if err := di.processDependency(dep); err != nil {
 		return fmt.Errorf("error processing dependency: %w", err)
 	}
 
-	return nil
+	unaliased := di.unaliasedImports(existing)
+	networks := make([]string, 0, len(unaliased))
+	for network := range unaliased {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+
+	for _, network := range networks {
+		di.Logger.Info(fmt.Sprintf(
+			"%s The aliases provided for %s are not added to its imports, %s have no alias on %s. Add them with 'flow dependencies add --alias' or in flow.json",
+			output.WarningEmoji(),
+			name,
+			strings.Join(unaliased[network], ", "),
+			network,
+		))
+	}
+
+	return di.save()
 }
 
-func (di *DependencyInstaller) processDependency(dependency config.Dependency) error {
-	depAddress := flowsdk.HexToAddress(dependency.Source.Address.String())
-	return di.fetchDependencies(dependency.Source.NetworkName, depAddress, dependency.Name, dependency.Source.ContractName)
+// unaliasedImports returns the dependencies installed for the imports of the added dependency that have
+// no alias on the networks of the provided aliases, by network. Provided aliases only apply to the added
+// dependency since its imports are usually deployed to other addresses.
+func (di *DependencyInstaller) unaliasedImports(existing map[string]bool) map[string][]string {
+	unaliased := make(map[string][]string)
+
+	for _, dependency := range *di.State.Dependencies() {
+		if existing[dependency.Name] || dependency.Name == di.aliasedName {
+			continue
+		}
+
+		contract, err := di.State.Contracts().ByName(dependency.Name)
+		if err != nil {
+			continue
+		}
+
+		for network := range di.Aliases {
+			if contract.Aliases.ByNetwork(network) == nil {
+				unaliased[network] = append(unaliased[network], dependency.Name)
+			}
+		}
+	}
+
+	for network := range unaliased {
+		sort.Strings(unaliased[network])
+	}
+
+	return unaliased
 }
 
-func (di *DependencyInstaller) fetchDependencies(networkName string, address flowsdk.Address, assignedName, contractName string) error {
-	account, err := di.Gateways[networkName].GetAccount(address)
+// Update installs the latest version of the named dependencies without prompting, all dependencies are updated if no names are provided
+func (di *DependencyInstaller) Update(names []string) error {
+	if err := di.start(); err != nil {
+		return err
+	}
+
+	dependencies, err := di.selectDependencies(names)
 	if err != nil {
-		return fmt.Errorf("failed to get account: %w", err)
+		return err
 	}
-	if account == nil {
-		return fmt.Errorf("account is nil for address: %s", address)
+
+	di.update = true
+	defer func() { di.update = false }()
+
+	for _, dependency := range dependencies {
+		if err := di.processDependency(dependency); err != nil {
+			return fmt.Errorf("error updating dependency %s: %w", dependency.Name, err)
+		}
 	}
 
-	if account.Contracts == nil {
-		return fmt.Errorf("contracts are nil for account: %s", address)
+	if err := di.installExternal(names); err != nil {
+		return err
 	}
 
-	var wg sync.WaitGroup
-	errCh := make(chan error, len(account.Contracts))
+	return di.save()
+}
 
-	// Create a max number of goroutines so that we don't rate limit the access node
-	maxGoroutines := 5
-	semaphore := make(chan struct{}, maxGoroutines)
+// Remove deletes the dependency from the project together with the transitive dependencies nothing else imports anymore,
+// it returns the names of all the removed dependencies
+//
+// A dependency that is still imported by a project contract or another dependency is only removed if forced or confirmed.
+func (di *DependencyInstaller) Remove(name string) ([]string, error) {
+	if err := di.start(); err != nil {
+		return nil, err
+	}
 
-	found := false
+	if _, ok := di.external[name]; !ok && di.State.Dependencies().ByName(name) == nil {
+		return nil, fmt.Errorf("dependency %s does not exist in configuration", name)
+	}
 
-	for _, contract := range account.Contracts {
-		program, err := project.NewProgram(contract, nil, "")
-		if err != nil {
-			return fmt.Errorf("failed to parse program: %w", err)
+	projectImports, err := di.projectImports()
+	if err != nil {
+		return nil, err
+	}
+
+	graph := di.installedImportGraph()
+	candidates := graph.reachable([]string{name}, "")
+
+	// dependencies which were not pulled in by the removed dependency and project contracts keep their imports installed
+	roots := make([]string, 0)
+	for dependency := range projectImports {
+		roots = append(roots, dependency)
+	}
+	for dependency := range graph {
+		if !candidates[dependency] {
+			roots = append(roots, dependency)
+		}
+	}
+	kept := graph.reachable(roots, name)
+
+	removed := make(map[string]bool)
+	for candidate := range candidates {
+		if !kept[candidate] {
+			removed[candidate] = true
+		}
+	}
+
+	importers := append([]string{}, projectImports[name]...)
+	for dependency, imports := range graph {
+		if !removed[dependency] && slices.Contains(imports, name) {
+			importers = append(importers, dependency)
+		}
+	}
+	sort.Strings(importers)
+
+	if len(importers) > 0 {
+		msg := fmt.Sprintf("%s is still imported by %s", name, strings.Join(importers, ", "))
+		if !di.Force && !di.confirm(fmt.Sprintf("%s. Do you want to remove it anyway?", msg)) {
+			return nil, fmt.Errorf("%s, use --force to remove it anyway", msg)
+		}
+		di.Logger.Info(fmt.Sprintf("%s %s", output.WarningEmoji(), msg))
+	}
+
+	for _, dependency := range sortedNames(removed) {
+		if err := di.removeDependency(dependency); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := di.save(); err != nil {
+		return nil, err
+	}
+
+	return sortedNames(removed), nil
+}
+
+// confirm asks the developer to confirm the message.
+func (di *DependencyInstaller) confirm(msg string) bool {
+	if di.prompt != nil {
+		return di.prompt(msg)
+	}
+
+	return util.GenericBoolPrompt(msg)
+}
+
+// fileRemover is implemented by readers and writers which can delete files, such as afero.Afero.
+type fileRemover interface {
+	Remove(name string) error
+}
+
+// removeDependency removes the dependency, its contract and installed file from the project.
+func (di *DependencyInstaller) removeDependency(name string) error {
+	path := ""
+	if _, ok := di.external[name]; ok {
+		if contract, err := di.State.Contracts().ByName(name); err == nil {
+			path = contract.Location
 		}
+		delete(di.external, name)
+	} else {
+		dependencies := di.State.Dependencies()
+		dependency := *dependencies.ByName(name)
+		path = contractFilePath(dependency.Source.Address.String(), dependency.Source.ContractName)
 
-		parsedContractName, err := program.Name()
+		remaining := make(config.Dependencies, 0, len(*dependencies))
+		for _, dep := range *dependencies {
+			if dep.Name != name {
+				remaining = append(remaining, dep)
+			}
+		}
+		*dependencies = remaining
+	}
+
+	_ = di.State.Contracts().Remove(name)
+	for i := range *di.State.Deployments() {
+		(*di.State.Deployments())[i].RemoveContract(name)
+	}
+	di.lockfile.Remove(name)
+
+	if path != "" {
+		remover, ok := di.State.ReaderWriter().(fileRemover)
+		if !ok {
+			return fmt.Errorf("unable to remove file %s", path)
+		}
+		if err := remover.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error removing file: %w", err)
+		}
+	}
+
+	di.Logger.Info(fmt.Sprintf("Dependency Manager: %s removed", name))
+
+	return nil
+}
+
+// projectImports returns the names of the project's own contracts importing each dependency.
+func (di *DependencyInstaller) projectImports() (map[string][]string, error) {
+	importers := make(map[string][]string)
+
+	for _, contract := range *di.State.Contracts() {
+		if di.isDependency(contract.Name) {
+			continue
+		}
+
+		code, err := di.State.ReadFile(contract.Location)
 		if err != nil {
-			return fmt.Errorf("failed to parse contract name: %w", err)
+			continue // aliased contracts don't need to exist locally
 		}
 
-		if parsedContractName == contractName {
-			found = true
+		imports, err := importedContracts(code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse imports of %s: %w", contract.Name, err)
+		}
 
-			if err := di.handleFoundContract(networkName, address.String(), assignedName, parsedContractName, program); err != nil {
-				return fmt.Errorf("failed to handle found contract: %w", err)
+		for _, imported := range imports {
+			if dependency, ok := di.importedDependency(imported); ok && !slices.Contains(importers[dependency], contract.Name) {
+				importers[dependency] = append(importers[dependency], contract.Name)
 			}
+		}
+	}
+
+	return importers, nil
+}
+
+// OutdatedDependency is a dependency whose on-chain code differs from the installed version.
+type OutdatedDependency struct {
+	Name       string
+	Source     string
+	LocalHash  string
+	RemoteHash string
+	Diff       string
+}
+
+// Outdated compares every dependency against its source and returns the ones that changed
+func (di *DependencyInstaller) Outdated() ([]OutdatedDependency, error) {
+	if err := di.start(); err != nil {
+		return nil, err
+	}
+	di.readOnly = true
+
+	outdated := make([]OutdatedDependency, 0)
+
+	for _, dependency := range *di.State.Dependencies() {
+		source := dependency.Source
+		program, err := di.fetchContract(source.NetworkName, source.Address, source.ContractName)
+		if err != nil {
+			return nil, err
+		}
+
+		remoteHash := contractHash(program)
+		if remoteHash == dependency.Hash {
+			continue
+		}
+
+		// a missing local file is shown as a diff against empty code
+		localCode, _ := di.State.ReaderWriter().ReadFile(contractFilePath(source.Address.String(), source.ContractName))
+		program.ConvertAddressImports()
+
+		outdated = append(outdated, OutdatedDependency{
+			Name:       dependency.Name,
+			Source:     sourceString(source.NetworkName, source.Address.String(), source.ContractName),
+			LocalHash:  dependency.Hash,
+			RemoteHash: remoteHash,
+			Diff:       util.ContractDiff(localCode, program.CodeWithUnprocessedImports()),
+		})
+	}
+
+	external, err := di.outdatedExternal()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(outdated, external...), nil
+}
+
+// selectDependencies returns the dependencies with the provided names, or all dependencies if no names are provided.
+func (di *DependencyInstaller) selectDependencies(names []string) ([]config.Dependency, error) {
+	if len(names) == 0 {
+		return *di.State.Dependencies(), nil
+	}
+
+	dependencies := make([]config.Dependency, 0, len(names))
+	for _, name := range names {
+		if _, ok := di.external[name]; ok {
+			continue // updated by installExternal
+		}
+
+		dependency := di.State.Dependencies().ByName(name)
+		if dependency == nil {
+			return nil, fmt.Errorf("dependency %s does not exist in configuration", name)
+		}
+		dependencies = append(dependencies, *dependency)
+	}
+
+	return dependencies, nil
+}
+
+// gateway returns the gateway for the network, creating it on first use, or an error if the network is not configured.
+func (di *DependencyInstaller) gateway(networkName string) (gateway.Gateway, error) {
+	di.gatewayMutex.Lock()
+	defer di.gatewayMutex.Unlock()
+
+	if gw, ok := di.Gateways[networkName]; ok {
+		return gw, nil
+	}
+
+	network, ok := di.networks[networkName]
+	if !ok {
+		return nil, fmt.Errorf("network %s is not configured, add it using 'flow config add network'", networkName)
+	}
+
+	gw, err := command.CreateGateway(network)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s gateway: %v", networkName, err)
+	}
+
+	if di.Gateways == nil {
+		di.Gateways = make(map[string]gateway.Gateway)
+	}
+	di.Gateways[networkName] = gw
+
+	return gw, nil
+}
+
+// networkConfigured reports whether the installer has or can create a gateway for the network.
+func (di *DependencyInstaller) networkConfigured(networkName string) bool {
+	di.gatewayMutex.Lock()
+	defer di.gatewayMutex.Unlock()
+
+	_, hasGateway := di.Gateways[networkName]
+	_, hasNetwork := di.networks[networkName]
+	return hasGateway || hasNetwork
+}
+
+var errContractNotFound = errors.New("contract not found")
+
+// fetchContract gets the contract code deployed on the network, or from the cache when offline.
+func (di *DependencyInstaller) fetchContract(networkName string, address flowsdk.Address, contractName string) (*project.Program, error) {
+	var code []byte
+	var err error
+
+	if di.Offline {
+		code, err = di.cachedContract(networkName, address, contractName)
+	} else {
+		code, err = di.remoteContract(networkName, address, contractName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := project.NewProgram(code, nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse program: %w", err)
+	}
+
+	return program, nil
+}
+
+// remoteContract gets the contract code from the network and stores it in the cache unless the installer is read-only.
+func (di *DependencyInstaller) remoteContract(networkName string, address flowsdk.Address, contractName string) ([]byte, error) {
+	gw, err := di.gateway(networkName)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := gw.GetAccount(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if account == nil {
+		return nil, fmt.Errorf("account is nil for address: %s", address)
+	}
+
+	if account.Contracts == nil {
+		return nil, fmt.Errorf("contracts are nil for account: %s", address)
+	}
+
+	code, ok := account.Contracts[contractName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s for account %s on network %s", errContractNotFound, contractName, address, networkName)
+	}
+
+	// only installed versions become the latest cached version
+	if di.Cache != nil && !di.readOnly {
+		if _, err := di.Cache.Put(networkName, address, contractName, code); err != nil {
+			di.Logger.Debug(fmt.Sprintf("Dependency Manager: failed caching %s: %v", contractName, err))
+		}
+	}
+
+	return code, nil
+}
 
-			if program.HasAddressImports() {
-				imports := program.AddressImportDeclarations()
-				for _, imp := range imports {
-					wg.Add(1)
-					go func(importAddress flowsdk.Address, contractName string) {
-						semaphore <- struct{}{}
-						defer func() {
-							<-semaphore
-							wg.Done()
-						}()
-						err := di.fetchDependencies(networkName, importAddress, contractName, contractName)
-						if err != nil {
-							errCh <- err
-						}
-					}(flowsdk.HexToAddress(imp.Location.String()), imp.Identifiers[0].String())
-				}
+// cachedContract gets the contract code from the cache, preferring the version pinned in the lockfile or configuration.
+func (di *DependencyInstaller) cachedContract(networkName string, address flowsdk.Address, contractName string) ([]byte, error) {
+	if di.Cache == nil {
+		return nil, fmt.Errorf("dependency cache is not available in offline mode")
+	}
+
+	code, err := di.Cache.Get(networkName, address, contractName, di.pinnedHash(networkName, address, contractName))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not available offline: %w", sourceString(networkName, address.String(), contractName), err)
+	}
+
+	return code, nil
+}
+
+// pinnedHash returns the hash recorded for the source in the lockfile or the configuration, or empty if none is recorded.
+func (di *DependencyInstaller) pinnedHash(networkName string, address flowsdk.Address, contractName string) string {
+	di.Mutex.Lock()
+	defer di.Mutex.Unlock()
+
+	source := sourceString(networkName, address.String(), contractName)
+	if di.lockfile != nil {
+		for _, locked := range di.lockfile.Dependencies {
+			if locked.Source == source {
+				return locked.Hash
 			}
 		}
 	}
 
-	if !found {
-		errMsg := fmt.Sprintf("contract %s not found for account %s on network %s", contractName, address, networkName)
-		di.Logger.Error(errMsg)
+	for _, dependency := range *di.State.Dependencies() {
+		if sameSource(dependency.Source, networkName, address.String(), contractName) && dependency.Hash != "" {
+			return dependency.Hash
+		}
+	}
+
+	return ""
+}
+
+// Plan returns the changes collected by the last dry run, or nil if dry-run mode is disabled.
+func (di *DependencyInstaller) Plan() *Plan {
+	return di.plan
+}
+
+// start prepares the installer for a new run and reads the lockfile, in frozen mode every declared dependency must be locked.
+func (di *DependencyInstaller) start() error {
+	switch di.OnConflict {
+	case "", ConflictFail, ConflictKeep, ConflictReplace, ConflictRename:
+	default:
+		return fmt.Errorf("invalid conflict strategy %s, valid strategies are: %s, %s, %s, %s", di.OnConflict, ConflictFail, ConflictKeep, ConflictReplace, ConflictRename)
+	}
+
+	lock, err := LoadLockfile(di.State.ReaderWriter())
+	if err != nil {
+		return err
+	}
+
+	external, err := loadExternalDependencies(di.State.ReaderWriter(), di.ConfigPaths)
+	if err != nil {
+		return err
+	}
+
+	if di.Frozen {
+		if missing := unlockedDependencies(lock, di.State, external); len(missing) > 0 {
+			return fmt.Errorf(
+				"frozen install requires %s to record dependencies %s, run 'flow dependencies install' without --frozen first",
+				LockfilePath, strings.Join(missing, ", "),
+			)
+		}
+	}
+
+	di.lockfile = lock
+	di.external = external
+	di.blockHeights = make(map[string]uint64)
+	di.conflicts = nil
+	di.repositories = make(map[string]*git.Repository)
+	di.externals = make(map[string]string)
+	di.plan = nil
+	di.readOnly = di.DryRun
+	if di.DryRun {
+		di.plan = newPlan(di.State, di.external)
+	}
+
+	return nil
+}
+
+// unlockedDependencies returns the sorted names of the declared dependencies that are not recorded in the lockfile.
+func unlockedDependencies(lock *Lockfile, state *flowkit.State, external ExternalDependencies) []string {
+	var missing []string
+	for _, dependency := range *state.Dependencies() {
+		if lock.ByName(dependency.Name) == nil {
+			missing = append(missing, dependency.Name)
+		}
+	}
+
+	for name := range external {
+		if lock.ByName(name) == nil {
+			missing = append(missing, name)
+		}
+	}
+
+	sort.Strings(missing)
+	return missing
+}
+
+// save persists the configuration and the lockfile once all dependencies are processed,
+// nothing is saved if any unresolved conflicts were found.
+func (di *DependencyInstaller) save() error {
+	if len(di.conflicts) > 0 {
+		return &ConflictError{Conflicts: di.conflicts}
+	}
+
+	if di.DryRun {
+		di.plan.finish(di.State, di.external)
+		return nil
+	}
+
+	configPath, err := util.ConfigEditPath(di.ConfigPaths)
+	if err != nil {
+		return err
+	}
+
+	if err := di.State.Save(configPath); err != nil {
+		return err
+	}
+
+	if err := di.external.save(di.State.ReaderWriter(), di.ConfigPaths); err != nil {
+		return err
+	}
+
+	return di.saveLockfile()
+}
+
+// saveLockfile persists the lockfile, in frozen mode the lockfile is never modified.
+func (di *DependencyInstaller) saveLockfile() error {
+	if di.Frozen {
+		return nil
+	}
+
+	return di.lockfile.Save(di.State.ReaderWriter())
+}
+
+// blockHeight returns the latest block height of the network, fetched once per installation.
+func (di *DependencyInstaller) blockHeight(networkName string) (uint64, error) {
+	di.Mutex.Lock()
+	defer di.Mutex.Unlock()
+
+	if height, ok := di.blockHeights[networkName]; ok {
+		return height, nil
+	}
+
+	gw, err := di.gateway(networkName)
+	if err != nil {
+		return 0, err
+	}
+
+	block, err := gw.GetLatestBlock()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest block for network %s: %w", networkName, err)
+	}
+
+	di.blockHeights[networkName] = block.Height
+
+	return block.Height, nil
+}
+
+// verifyLocked checks the remote contract matches the one recorded in the lockfile.
+func (di *DependencyInstaller) verifyLocked(networkName, contractAddr, assignedName, contractName, contractHash string) error {
+	di.Mutex.Lock()
+	defer di.Mutex.Unlock()
+
+	locked := di.lockfile.ByName(assignedName)
+	if locked == nil {
+		return fmt.Errorf("dependency %s is not recorded in %s", assignedName, LockfilePath)
+	}
+
+	source := sourceString(networkName, contractAddr, contractName)
+	if locked.Source != source {
+		return fmt.Errorf("dependency %s source %s does not match locked source %s", assignedName, source, locked.Source)
+	}
+
+	if locked.Hash != contractHash {
+		return fmt.Errorf(
+			"dependency %s changed since it was locked at block height %d: locked hash %s, remote hash %s",
+			assignedName,
+			locked.BlockHeight,
+			locked.Hash,
+			contractHash,
+		)
+	}
+
+	return nil
+}
+
+// lockDependency records the installed dependency in the lockfile.
+//
+// When offline the block height can't be fetched, so the previously locked height is kept if the code didn't change.
+func (di *DependencyInstaller) lockDependency(networkName, contractAddr, assignedName, contractName, contractHash string) error {
+	var height uint64
+	if !di.Offline {
+		var err error
+		height, err = di.blockHeight(networkName)
+		if err != nil {
+			return err
+		}
+	}
+
+	di.Mutex.Lock()
+	defer di.Mutex.Unlock()
+
+	if locked := di.lockfile.ByName(assignedName); di.Offline && locked != nil && locked.Hash == contractHash {
+		height = locked.BlockHeight
+	}
+
+	di.lockfile.AddOrUpdate(assignedName, LockedDependency{
+		Source:      sourceString(networkName, contractAddr, contractName),
+		Hash:        contractHash,
+		BlockHeight: height,
+	})
+
+	return nil
+}
+
+func (di *DependencyInstaller) processDependency(dependency config.Dependency) error {
+	depAddress := flowsdk.HexToAddress(dependency.Source.Address.String())
+	return di.fetchDependencies(dependency.Source.NetworkName, depAddress, dependency.Name, dependency.Source.ContractName)
+}
+
+func (di *DependencyInstaller) fetchDependencies(networkName string, address flowsdk.Address, assignedName, contractName string) error {
+	program, err := di.fetchContract(networkName, address, contractName)
+	if errors.Is(err, errContractNotFound) {
+		di.Logger.Error(err.Error())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	installed, err := di.handleFoundContract(networkName, address.String(), assignedName, contractName, program)
+	if err != nil {
+		return fmt.Errorf("failed to handle found contract: %w", err)
+	}
+
+	if !installed || !program.HasAddressImports() {
+		return nil
+	}
+
+	imports := program.AddressImportDeclarations()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(imports))
+
+	// Create a max number of goroutines so that we don't rate limit the access node
+	maxGoroutines := 5
+	semaphore := make(chan struct{}, maxGoroutines)
+
+	for _, imp := range imports {
+		wg.Add(1)
+		go func(importAddress flowsdk.Address, contractName string) {
+			semaphore <- struct{}{}
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			err := di.fetchDependencies(networkName, importAddress, contractName, contractName)
+			if err != nil {
+				errCh <- err
+			}
+		}(flowsdk.HexToAddress(imp.Location.String()), imp.Identifiers[0].String())
 	}
 
 	wg.Wait()
@@ -200,18 +848,25 @@ func (di *DependencyInstaller) fetchDependencies(networkName string, address flo
 	return nil
 }
 
-func (di *DependencyInstaller) contractFileExists(address, contractName string) bool {
+// contractFilePath returns the path the dependency manager installs a contract to.
+func contractFilePath(address, contractName string) string {
 	fileName := fmt.Sprintf("%s.cdc", contractName)
-	path := filepath.Join("imports", address, fileName)
+	return filepath.Join("imports", address, fileName)
+}
 
-	_, err := di.State.ReaderWriter().Stat(path)
+// contractHash computes the hash of the contract code as deployed on-chain, this is the hash stored in flow.json.
+func contractHash(program *project.Program) string {
+	return codeHash(program.CodeWithUnprocessedImports())
+}
+
+func (di *DependencyInstaller) contractFileExists(address, contractName string) bool {
+	_, err := di.State.ReaderWriter().Stat(contractFilePath(address, contractName))
 
 	return err == nil
 }
 
 func (di *DependencyInstaller) createContractFile(address, contractName, data string) error {
-	fileName := fmt.Sprintf("%s.cdc", contractName)
-	path := filepath.Join("imports", address, fileName)
+	path := contractFilePath(address, contractName)
 	dir := filepath.Dir(path)
 
 	if err := di.State.ReaderWriter().MkdirAll(dir, 0755); err != nil {
@@ -225,11 +880,16 @@ func (di *DependencyInstaller) createContractFile(address, contractName, data st
 	return nil
 }
 
-func (di *DependencyInstaller) handleFileSystem(contractAddr, contractName, contractData, networkName string) error {
+func (di *DependencyInstaller) handleFileSystem(contractAddr, contractName, contractData, networkName string, overwrite bool) error {
 	di.Mutex.Lock()
 	defer di.Mutex.Unlock()
 
-	if !di.contractFileExists(contractAddr, contractName) {
+	if di.DryRun {
+		di.plan.addFile(di.State.ReaderWriter(), contractFilePath(contractAddr, contractName), []byte(contractData))
+		return nil
+	}
+
+	if overwrite || !di.contractFileExists(contractAddr, contractName) {
 		if err := di.createContractFile(contractAddr, contractName, contractData); err != nil {
 			return fmt.Errorf("failed to create contract file: %w", err)
 		}
@@ -240,48 +900,119 @@ func (di *DependencyInstaller) handleFileSystem(contractAddr, contractName, cont
 	return nil
 }
 
-func (di *DependencyInstaller) handleFoundContract(networkName, contractAddr, assignedName, contractName string, program *project.Program) error {
-	hash := sha256.New()
-	hash.Write(program.CodeWithUnprocessedImports())
-	originalContractDataHash := hex.EncodeToString(hash.Sum(nil))
+// handleFoundContract installs the contract, it returns false if the contract was skipped because of a conflict.
+func (di *DependencyInstaller) handleFoundContract(networkName, contractAddr, assignedName, contractName string, program *project.Program) (bool, error) {
+	originalContractDataHash := contractHash(program)
 
 	program.ConvertAddressImports()
 	contractData := string(program.CodeWithUnprocessedImports())
 
+	assignedName, ok := di.resolveConflict(networkName, contractAddr, assignedName, contractName)
+	if !ok {
+		return false, nil
+	}
+
 	dependency := di.State.Dependencies().ByName(assignedName)
 
-	// If a dependency by this name already exists and its remote source network or address does not match, then give option to stop or continue
-	if dependency != nil && (dependency.Source.NetworkName != networkName || dependency.Source.Address.String() != contractAddr) {
-		di.Logger.Info(fmt.Sprintf("🚫 A dependency named %s already exists with a different remote source. Please fix the conflict and retry.", assignedName))
-		os.Exit(0)
-		return nil
+	// In frozen mode the lockfile is the source of truth, any difference fails the installation
+	if di.Frozen {
+		if err := di.verifyLocked(networkName, contractAddr, assignedName, contractName, originalContractDataHash); err != nil {
+			return false, err
+		}
 	}
 
 	// Check if remote source version is different from local version
-	// If it is, ask if they want to update
+	// If it is, ask if they want to update unless we are updating or frozen
 	// If no hash, ignore
-	if dependency != nil && dependency.Hash != "" && dependency.Hash != originalContractDataHash {
+	changed := dependency != nil && dependency.Hash != "" && dependency.Hash != originalContractDataHash
+	if changed && di.DryRun {
+		di.Mutex.Lock()
+		di.plan.addHash(assignedName, dependency.Hash, originalContractDataHash)
+		di.Mutex.Unlock()
+	} else if changed && !di.Frozen && !di.update {
 		msg := fmt.Sprintf("The latest version of %s is different from the one you have locally. Do you want to update it?", contractName)
 		if !util.GenericBoolPrompt(msg) {
-			return nil
+			return true, nil
 		}
 	}
 
-	err := di.handleFileSystem(contractAddr, contractName, contractData, networkName)
+	err := di.handleFileSystem(contractAddr, contractName, contractData, networkName, changed)
 	if err != nil {
-		return fmt.Errorf("error handling file system: %w", err)
+		return false, fmt.Errorf("error handling file system: %w", err)
 	}
 
-	err = di.updateState(networkName, contractAddr, assignedName, contractName, originalContractDataHash)
+	di.updateState(networkName, contractAddr, assignedName, contractName, originalContractDataHash)
+
+	err = di.lockDependency(networkName, contractAddr, assignedName, contractName, originalContractDataHash)
 	if err != nil {
-		di.Logger.Error(fmt.Sprintf("Error updating state: %v", err))
-		return err
+		return false, fmt.Errorf("error updating lockfile: %w", err)
 	}
 
-	return nil
+	return true, nil
+}
+
+// resolveConflict checks whether a dependency with the same name but a different source exists and resolves it
+// using the conflict strategy, it returns the name to install the dependency under or false if it should be skipped.
+func (di *DependencyInstaller) resolveConflict(networkName, contractAddr, assignedName, contractName string) (string, bool) {
+	di.Mutex.Lock()
+	defer di.Mutex.Unlock()
+
+	dependency := di.State.Dependencies().ByName(assignedName)
+	if dependency == nil || sameSource(dependency.Source, networkName, contractAddr, contractName) {
+		return assignedName, true
+	}
+
+	conflict := DependencyConflict{
+		Name:           assignedName,
+		ExistingSource: sourceString(dependency.Source.NetworkName, dependency.Source.Address.String(), dependency.Source.ContractName),
+		NewSource:      sourceString(networkName, contractAddr, contractName),
+	}
+
+	switch di.OnConflict {
+	case ConflictKeep:
+		di.Logger.Info(fmt.Sprintf("Dependency Manager: keeping %s from %s, skipping %s", conflict.Name, conflict.ExistingSource, conflict.NewSource))
+		return "", false
+	case ConflictReplace:
+		di.Logger.Info(fmt.Sprintf("Dependency Manager: replacing %s from %s with %s", conflict.Name, conflict.ExistingSource, conflict.NewSource))
+		return assignedName, true
+	case ConflictRename:
+		name := renamedDependency(di.State.Dependencies(), assignedName, networkName, contractAddr, contractName)
+		di.Logger.Info(fmt.Sprintf("Dependency Manager: installing %s from %s as %s", conflict.Name, conflict.NewSource, name))
+		return name, true
+	default:
+		di.conflicts = append(di.conflicts, conflict)
+		return "", false
+	}
+}
+
+// sameSource checks if the source points to the same contract.
+func sameSource(source config.Source, networkName, contractAddr, contractName string) bool {
+	return source.NetworkName == networkName &&
+		source.Address.String() == flowsdk.HexToAddress(contractAddr).String() &&
+		source.ContractName == contractName
 }
 
-func (di *DependencyInstaller) updateState(networkName, contractAddress, assignedName, contractName, contractHash string) error {
+// renamedDependency returns a name for a conflicting dependency which is not used by a different source.
+func renamedDependency(dependencies *config.Dependencies, name, networkName, contractAddr, contractName string) string {
+	candidates := []string{
+		fmt.Sprintf("%s_%s", name, contractAddr),
+		fmt.Sprintf("%s_%s_%s", name, networkName, contractAddr),
+	}
+
+	for _, candidate := range candidates {
+		existing := dependencies.ByName(candidate)
+		if existing == nil || sameSource(existing.Source, networkName, contractAddr, contractName) {
+			return candidate
+		}
+	}
+
+	return candidates[len(candidates)-1]
+}
+
+func (di *DependencyInstaller) updateState(networkName, contractAddress, assignedName, contractName, contractHash string) {
+	di.Mutex.Lock()
+	defer di.Mutex.Unlock()
+
 	dep := config.Dependency{
 		Name: assignedName,
 		Source: config.Source{
@@ -294,16 +1025,49 @@ func (di *DependencyInstaller) updateState(networkName, contractAddress, assigne
 
 	isNewDep := di.State.Dependencies().ByName(dep.Name) == nil
 
+	// aliases already configured for the contract are kept, so users can provide addresses for other networks
+	var existingAliases config.Aliases
+	if contract, err := di.State.Contracts().ByName(dep.Name); err == nil {
+		existingAliases = contract.Aliases
+	}
+
 	di.State.Dependencies().AddOrUpdate(dep)
 	di.State.Contracts().AddDependencyAsContract(dep, networkName)
-	err := di.State.SaveDefault()
-	if err != nil {
-		return err
-	}
+	di.addNetworkAliases(dep, existingAliases)
 
 	if isNewDep {
 		di.Logger.Info(fmt.Sprintf("Dependency Manager: %s added to flow.json", dep.Name))
 	}
+}
 
-	return nil
+// addNetworkAliases adds aliases of the dependency contract for every configured network.
+//
+// Aliases which already exist are kept, core contracts get their well-known address on each network,
+// and the aliases provided to the installer are only added to the dependency being added, not to its imports.
+func (di *DependencyInstaller) addNetworkAliases(dep config.Dependency, existing config.Aliases) {
+	contract, err := di.State.Contracts().ByName(dep.Name)
+	if err != nil {
+		return
+	}
+
+	for _, alias := range existing {
+		contract.Aliases.Add(alias.Network, alias.Address)
+	}
+
+	if dep.Name == di.aliasedName {
+		for network, address := range di.Aliases {
+			contract.Aliases.Add(network, address)
+		}
+	}
+
+	core, isCore := util.CoreContractByAddress(dep.Source.ContractName, dep.Source.NetworkName, dep.Source.Address)
+	if !isCore {
+		return
+	}
+
+	for network, address := range core.Addresses {
+		if di.networkConfigured(network) {
+			contract.Aliases.Add(network, address)
+		}
+	}
 }
@@ -19,13 +19,22 @@
 package dependencymanager
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/onflow/flowkit"
 	"github.com/onflow/flowkit/config"
 	"github.com/onflow/flowkit/gateway"
 	"github.com/onflow/flowkit/gateway/mocks"
@@ -35,6 +44,41 @@ import (
 	"github.com/onflow/flow-cli/internal/util"
 )
 
+func TestNewDependencyInstaller(t *testing.T) {
+
+	logger := output.NewStdoutLogger(output.NoneLog)
+	srv, state, _ := util.TestMocks(t)
+
+	state.Networks().AddOrUpdate(config.Network{Name: "mynet", Host: "127.0.0.1:3570"})
+
+	gw := mocks.DefaultMockGateway()
+	srv.Gateway.Return(gw.Mock)
+
+	di, err := NewDependencyInstaller(logger, state, srv.Mock)
+	assert.NoError(t, err)
+
+	// the network selected for the command uses its gateway, so host overrides are respected
+	assert.Equal(t, map[string]gateway.Gateway{config.EmulatorNetwork.Name: gw.Mock}, di.Gateways)
+
+	for _, network := range []string{"emulator", "testnet", "mainnet", "mynet"} {
+		assert.True(t, di.networkConfigured(network))
+	}
+
+	// other gateways are only created once their network is used
+	mynet, err := di.gateway("mynet")
+	assert.NoError(t, err)
+	assert.Contains(t, di.Gateways, "mynet")
+	assert.NotContains(t, di.Gateways, config.TestnetNetwork.Name)
+
+	cached, err := di.gateway("mynet")
+	assert.NoError(t, err)
+	assert.Same(t, mynet, cached)
+
+	assert.False(t, di.networkConfigured("unknown"))
+	_, err = di.gateway("unknown")
+	assert.ErrorContains(t, err, "network unknown is not configured")
+}
+
 func TestDependencyInstallerInstall(t *testing.T) {
 
 	logger := output.NewStdoutLogger(output.NoneLog)
@@ -130,3 +174,788 @@ func TestDependencyInstallerAdd(t *testing.T) {
 		assert.NotNil(t, fileContent)
 	})
 }
+
+func TestDependencyInstallerLockfile(t *testing.T) {
+
+	_, state, _ := util.TestMocks(t)
+
+	serviceAcc, _ := state.EmulatorServiceAccount()
+	serviceAddress := serviceAcc.Address
+
+	newInstaller := func(contractSource []byte) *DependencyInstaller {
+		return newTestInstaller(state, helloContract(contractSource))
+	}
+
+	sourceStr := fmt.Sprintf("emulator://%s.%s", serviceAddress.String(), tests.ContractHelloString.Name)
+
+	t.Run("Frozen without dependencies", func(t *testing.T) {
+		di := newInstaller(tests.ContractHelloString.Source)
+		di.Frozen = true
+
+		err := di.Install()
+		assert.NoError(t, err)
+	})
+
+	t.Run("Frozen without lockfile", func(t *testing.T) {
+		state.Dependencies().AddOrUpdate(config.Dependency{
+			Name: tests.ContractHelloString.Name,
+			Source: config.Source{
+				NetworkName:  "emulator",
+				Address:      serviceAddress,
+				ContractName: tests.ContractHelloString.Name,
+			},
+		})
+
+		di := newInstaller(tests.ContractHelloString.Source)
+		di.Frozen = true
+
+		err := di.Install()
+		assert.ErrorContains(t, err, "frozen install requires flow.lock to record dependencies Hello")
+	})
+
+	t.Run("Records lockfile", func(t *testing.T) {
+		di := newInstaller(tests.ContractHelloString.Source)
+
+		err := di.Add(sourceStr, "")
+		assert.NoError(t, err, "Failed to install dependencies")
+
+		lock, err := LoadLockfile(state.ReaderWriter())
+		assert.NoError(t, err)
+
+		locked := lock.ByName(tests.ContractHelloString.Name)
+		assert.NotNil(t, locked)
+		assert.Equal(t, sourceStr, locked.Source)
+		assert.Equal(t, state.Dependencies().ByName(tests.ContractHelloString.Name).Hash, locked.Hash)
+	})
+
+	t.Run("Frozen success", func(t *testing.T) {
+		di := newInstaller(tests.ContractHelloString.Source)
+		di.Frozen = true
+
+		err := di.Install()
+		assert.NoError(t, err)
+	})
+
+	t.Run("Frozen fails on changed contract", func(t *testing.T) {
+		changed := append([]byte("// changed upstream\n"), tests.ContractHelloString.Source...)
+		di := newInstaller(changed)
+		di.Frozen = true
+
+		err := di.Install()
+		assert.ErrorContains(t, err, "changed since it was locked")
+	})
+}
+
+func TestDependencyInstallerUpdateAndOutdated(t *testing.T) {
+
+	_, state, _ := util.TestMocks(t)
+
+	serviceAcc, _ := state.EmulatorServiceAccount()
+	serviceAddress := serviceAcc.Address
+
+	newInstaller := func(contractSource []byte) *DependencyInstaller {
+		return newTestInstaller(state, helloContract(contractSource))
+	}
+
+	sourceStr := fmt.Sprintf("emulator://%s.%s", serviceAddress.String(), tests.ContractHelloString.Name)
+	filePath := fmt.Sprintf("imports/%s/%s.cdc", serviceAddress.String(), tests.ContractHelloString.Name)
+	changed := append([]byte("// changed upstream\n"), tests.ContractHelloString.Source...)
+
+	err := newInstaller(tests.ContractHelloString.Source).Add(sourceStr, "")
+	assert.NoError(t, err, "Failed to install dependencies")
+
+	t.Run("Outdated up to date", func(t *testing.T) {
+		outdated, err := newInstaller(tests.ContractHelloString.Source).Outdated()
+		assert.NoError(t, err)
+		assert.Len(t, outdated, 0)
+	})
+
+	t.Run("Outdated changed", func(t *testing.T) {
+		outdated, err := newInstaller(changed).Outdated()
+		assert.NoError(t, err)
+		assert.Len(t, outdated, 1)
+		assert.Equal(t, tests.ContractHelloString.Name, outdated[0].Name)
+		assert.Equal(t, sourceStr, outdated[0].Source)
+		assert.NotEqual(t, outdated[0].LocalHash, outdated[0].RemoteHash)
+		assert.Contains(t, outdated[0].Diff, "changed upstream")
+	})
+
+	t.Run("Update unknown", func(t *testing.T) {
+		err := newInstaller(changed).Update([]string{"Foo"})
+		assert.ErrorContains(t, err, "dependency Foo does not exist in configuration")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		err := newInstaller(changed).Update([]string{tests.ContractHelloString.Name})
+		assert.NoError(t, err)
+
+		fileContent, err := state.ReaderWriter().ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.Contains(t, string(fileContent), "changed upstream")
+
+		outdated, err := newInstaller(changed).Outdated()
+		assert.NoError(t, err)
+		assert.Len(t, outdated, 0)
+	})
+}
+
+func TestDependencyInstallerRemove(t *testing.T) {
+
+	_, state, _ := util.TestMocks(t)
+
+	serviceAcc, _ := state.EmulatorServiceAccount()
+	serviceAddress := serviceAcc.Address
+
+	di := newTestInstaller(state, func(address flow.Address) map[string][]byte {
+		return map[string][]byte{
+			"Bar": []byte(`pub contract Bar {}`),
+			"Foo": []byte(fmt.Sprintf("import Bar from 0x%s\npub contract Foo {}", address)),
+			"Baz": []byte(fmt.Sprintf("import Bar from 0x%s\npub contract Baz {}", address)),
+		}
+	})
+
+	for _, name := range []string{"Foo", "Baz"} {
+		err := di.Add(fmt.Sprintf("emulator://%s.%s", serviceAddress.String(), name), "")
+		assert.NoError(t, err, "Failed to install dependencies")
+	}
+	assert.NotNil(t, state.Dependencies().ByName("Bar"))
+
+	t.Run("Fail unknown", func(t *testing.T) {
+		_, err := di.Remove("Qux")
+		assert.ErrorContains(t, err, "dependency Qux does not exist in configuration")
+	})
+
+	t.Run("Refuse imported", func(t *testing.T) {
+		prompts := make([]string, 0)
+		di.prompt = func(msg string) bool {
+			prompts = append(prompts, msg)
+			return false
+		}
+		defer func() { di.prompt = nil }()
+
+		_, err := di.Remove("Bar")
+		assert.ErrorContains(t, err, "Bar is still imported by Baz, Foo, use --force to remove it anyway")
+
+		_ = state.ReaderWriter().WriteFile("cadence/contracts/Main.cdc", []byte("import \"Foo\"\npub contract Main {}"), 0644)
+		state.Contracts().AddOrUpdate(config.Contract{Name: "Main", Location: "cadence/contracts/Main.cdc"})
+		defer func() { _ = state.Contracts().Remove("Main") }()
+
+		_, err = di.Remove("Foo")
+		assert.ErrorContains(t, err, "Foo is still imported by Main, use --force to remove it anyway")
+		assert.Equal(t, []string{
+			"Bar is still imported by Baz, Foo. Do you want to remove it anyway?",
+			"Foo is still imported by Main. Do you want to remove it anyway?",
+		}, prompts)
+		assert.NotNil(t, state.Dependencies().ByName("Foo"))
+		assert.NotNil(t, state.Dependencies().ByName("Bar"))
+	})
+
+	t.Run("Keep shared import", func(t *testing.T) {
+		removed, err := di.Remove("Foo")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Foo"}, removed)
+
+		assert.Nil(t, state.Dependencies().ByName("Foo"))
+		assert.NotNil(t, state.Dependencies().ByName("Bar"))
+
+		_, err = state.Contracts().ByName("Foo")
+		assert.Error(t, err)
+
+		_, err = state.ReaderWriter().ReadFile(fmt.Sprintf("imports/%s/Foo.cdc", serviceAddress.String()))
+		assert.Error(t, err)
+	})
+
+	t.Run("Prune transitive", func(t *testing.T) {
+		removed, err := di.Remove("Baz")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Bar", "Baz"}, removed)
+		assert.Len(t, *state.Dependencies(), 0)
+
+		lock, err := LoadLockfile(state.ReaderWriter())
+		assert.NoError(t, err)
+		assert.Len(t, lock.Dependencies, 0)
+	})
+}
+
+func TestDependencyInstallerConflicts(t *testing.T) {
+
+	existingAddress := flow.HexToAddress("0x7e60df042a9c0868")
+
+	setup := func(t *testing.T, onConflict string) (*DependencyInstaller, string) {
+		_, state, _ := util.TestMocks(t)
+
+		serviceAcc, _ := state.EmulatorServiceAccount()
+		serviceAddress := serviceAcc.Address
+
+		state.Dependencies().AddOrUpdate(config.Dependency{
+			Name: tests.ContractHelloString.Name,
+			Source: config.Source{
+				NetworkName:  "testnet",
+				Address:      existingAddress,
+				ContractName: tests.ContractHelloString.Name,
+			},
+		})
+
+		di := newTestInstaller(state, helloContract(tests.ContractHelloString.Source))
+		di.OnConflict = onConflict
+
+		return di, fmt.Sprintf("emulator://%s.%s", serviceAddress.String(), tests.ContractHelloString.Name)
+	}
+
+	t.Run("Fail", func(t *testing.T) {
+		di, source := setup(t, ConflictFail)
+
+		err := di.Add(source, "")
+
+		var conflictErr *ConflictError
+		assert.True(t, errors.As(err, &conflictErr))
+		assert.Len(t, conflictErr.Conflicts, 1)
+		assert.Equal(t, tests.ContractHelloString.Name, conflictErr.Conflicts[0].Name)
+		assert.Equal(t, source, conflictErr.Conflicts[0].NewSource)
+
+		dep := di.State.Dependencies().ByName(tests.ContractHelloString.Name)
+		assert.Equal(t, existingAddress, dep.Source.Address)
+	})
+
+	t.Run("Keep", func(t *testing.T) {
+		di, source := setup(t, ConflictKeep)
+
+		err := di.Add(source, "")
+		assert.NoError(t, err)
+
+		dep := di.State.Dependencies().ByName(tests.ContractHelloString.Name)
+		assert.Equal(t, existingAddress, dep.Source.Address)
+		assert.Len(t, *di.State.Dependencies(), 1)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		di, source := setup(t, ConflictReplace)
+
+		err := di.Add(source, "")
+		assert.NoError(t, err)
+
+		dep := di.State.Dependencies().ByName(tests.ContractHelloString.Name)
+		assert.Equal(t, config.EmulatorNetwork.Name, dep.Source.NetworkName)
+		assert.Len(t, *di.State.Dependencies(), 1)
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		di, source := setup(t, ConflictRename)
+
+		err := di.Add(source, "")
+		assert.NoError(t, err)
+
+		serviceAcc, _ := di.State.EmulatorServiceAccount()
+		renamed := di.State.Dependencies().ByName(fmt.Sprintf("%s_%s", tests.ContractHelloString.Name, serviceAcc.Address.String()))
+		assert.NotNil(t, renamed)
+		assert.Equal(t, config.EmulatorNetwork.Name, renamed.Source.NetworkName)
+		assert.Len(t, *di.State.Dependencies(), 2)
+	})
+
+	t.Run("Invalid strategy", func(t *testing.T) {
+		di, source := setup(t, "merge")
+
+		err := di.Add(source, "")
+		assert.ErrorContains(t, err, "invalid conflict strategy merge")
+	})
+}
+
+func TestDependencyInstallerAliases(t *testing.T) {
+
+	setup := func(t *testing.T) *DependencyInstaller {
+		_, state, _ := util.TestMocks(t)
+
+		return newTestInstaller(state, func(address flow.Address) map[string][]byte {
+			return map[string][]byte{
+				"FlowToken": []byte(`pub contract FlowToken {}`),
+				"Foo":       []byte(`pub contract Foo {}`),
+				"Bar":       []byte(fmt.Sprintf("import Foo from 0x%s\npub contract Bar {}", address)),
+			}
+		})
+	}
+
+	t.Run("Core contract", func(t *testing.T) {
+		di := setup(t)
+
+		err := di.Add("testnet://7e60df042a9c0868.FlowToken", "")
+		assert.NoError(t, err)
+
+		contract, err := di.State.Contracts().ByName("FlowToken")
+		assert.NoError(t, err)
+		assert.Equal(t, "7e60df042a9c0868", contract.Aliases.ByNetwork(config.TestnetNetwork.Name).Address.String())
+		assert.Equal(t, "1654653399040a61", contract.Aliases.ByNetwork(config.MainnetNetwork.Name).Address.String())
+		assert.Equal(t, "0ae53cb6e3f42a79", contract.Aliases.ByNetwork(config.EmulatorNetwork.Name).Address.String())
+	})
+
+	t.Run("Provided aliases", func(t *testing.T) {
+		di := setup(t)
+
+		aliases, err := parseAliases([]string{"mainnet=0x01"})
+		assert.NoError(t, err)
+		di.Aliases = aliases
+
+		err = di.Add("testnet://0x02.Foo", "")
+		assert.NoError(t, err)
+
+		contract, err := di.State.Contracts().ByName("Foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "0000000000000002", contract.Aliases.ByNetwork(config.TestnetNetwork.Name).Address.String())
+		assert.Equal(t, "0000000000000001", contract.Aliases.ByNetwork(config.MainnetNetwork.Name).Address.String())
+		assert.Nil(t, contract.Aliases.ByNetwork(config.EmulatorNetwork.Name))
+
+		// aliases provided earlier are kept when the dependency is installed again
+		di.Aliases = nil
+		err = di.Install()
+		assert.NoError(t, err)
+
+		contract, err = di.State.Contracts().ByName("Foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "0000000000000001", contract.Aliases.ByNetwork(config.MainnetNetwork.Name).Address.String())
+	})
+
+	t.Run("Not added to imports", func(t *testing.T) {
+		di := setup(t)
+		di.Aliases = map[string]flow.Address{config.MainnetNetwork.Name: flow.HexToAddress("0x01")}
+
+		err := di.Add("testnet://0x02.Bar", "")
+		assert.NoError(t, err)
+
+		contract, err := di.State.Contracts().ByName("Bar")
+		assert.NoError(t, err)
+		assert.Equal(t, "0000000000000001", contract.Aliases.ByNetwork(config.MainnetNetwork.Name).Address.String())
+
+		contract, err = di.State.Contracts().ByName("Foo")
+		assert.NoError(t, err)
+		assert.Nil(t, contract.Aliases.ByNetwork(config.MainnetNetwork.Name))
+		assert.Equal(t, map[string][]string{config.MainnetNetwork.Name: {"Foo"}}, di.unaliasedImports(map[string]bool{}))
+	})
+
+	t.Run("Invalid alias", func(t *testing.T) {
+		_, err := parseAliases([]string{"mainnet"})
+		assert.ErrorContains(t, err, "invalid alias mainnet")
+	})
+}
+
+func TestDependencyInstallerOffline(t *testing.T) {
+
+	_, state, _ := util.TestMocks(t)
+
+	serviceAcc, _ := state.EmulatorServiceAccount()
+	serviceAddress := serviceAcc.Address
+
+	cache := NewCache(afero.NewMemMapFs(), "cache")
+	sourceStr := fmt.Sprintf("emulator://%s.%s", serviceAddress.String(), tests.ContractHelloString.Name)
+
+	newInstaller := func() *DependencyInstaller {
+		di := newTestInstaller(state, helloContract(tests.ContractHelloString.Source))
+		di.Cache = cache
+		return di
+	}
+
+	t.Run("Missing from cache", func(t *testing.T) {
+		di := newInstaller()
+		di.Offline = true
+
+		err := di.Add(sourceStr, "")
+		assert.ErrorContains(t, err, "is not available offline")
+	})
+
+	t.Run("Online install fills cache", func(t *testing.T) {
+		err := newInstaller().Add(sourceStr, "")
+		assert.NoError(t, err)
+
+		code, err := cache.Get("emulator", serviceAddress, tests.ContractHelloString.Name, "")
+		assert.NoError(t, err)
+		assert.Equal(t, tests.ContractHelloString.Source, code)
+	})
+
+	t.Run("Offline install uses cache", func(t *testing.T) {
+		di := newInstaller()
+		di.Offline = true
+
+		err := di.Install()
+		assert.NoError(t, err)
+
+		gw := di.Gateways[config.EmulatorNetwork.Name].(*mocks.Gateway)
+		gw.AssertNotCalled(t, "GetAccount", mock.Anything)
+		gw.AssertNotCalled(t, "GetLatestBlock")
+
+		lock, err := LoadLockfile(state.ReaderWriter())
+		assert.NoError(t, err)
+		assert.Equal(t, state.Dependencies().ByName(tests.ContractHelloString.Name).Hash, lock.ByName(tests.ContractHelloString.Name).Hash)
+	})
+
+	t.Run("Read-only commands keep latest", func(t *testing.T) {
+		older := []byte("// older\n")
+		_, err := cache.Put("emulator", serviceAddress, tests.ContractHelloString.Name, older)
+		assert.NoError(t, err)
+
+		_, err = newInstaller().Outdated()
+		assert.NoError(t, err)
+		_, err = newInstaller().Tree()
+		assert.NoError(t, err)
+		di := newInstaller()
+		di.DryRun = true
+		assert.NoError(t, di.Install())
+
+		code, err := cache.Get("emulator", serviceAddress, tests.ContractHelloString.Name, "")
+		assert.NoError(t, err)
+		assert.Equal(t, older, code)
+	})
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache(afero.NewMemMapFs(), "cache")
+	address := flow.HexToAddress("01")
+
+	first, err := cache.Put("testnet", address, "Foo", []byte("first"))
+	assert.NoError(t, err)
+	_, err = cache.Put("testnet", address, "Foo", []byte("second"))
+	assert.NoError(t, err)
+
+	code, err := cache.Get("testnet", address, "Foo", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("second"), code)
+
+	code, err = cache.Get("testnet", address, "Foo", first)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("first"), code)
+
+	_, err = cache.Get("mainnet", address, "Foo", "")
+	assert.ErrorContains(t, err, "no cached version found")
+
+	assert.NoError(t, cache.fs.WriteFile(fmt.Sprintf("cache/testnet/%s/Foo/%s.cdc", address.String(), first), []byte("tampered"), 0644))
+	_, err = cache.Get("testnet", address, "Foo", first)
+	assert.ErrorContains(t, err, "is corrupted")
+}
+
+func TestDependencyInstallerTree(t *testing.T) {
+
+	_, state, _ := util.TestMocks(t)
+
+	first, second, third := flow.HexToAddress("01"), flow.HexToAddress("02"), flow.HexToAddress("03")
+	contracts := map[flow.Address]map[string][]byte{
+		first: {
+			"Foo": []byte(fmt.Sprintf("import Bar from 0x%s\nimport Qux from 0x%s\npub contract Foo {}", second, second)),
+			"Baz": []byte(fmt.Sprintf("import Bar from 0x%s\npub contract Baz {}", second)),
+		},
+		second: {"Bar": []byte(`pub contract Bar {}`)},
+		third:  {"Bar": []byte(`pub contract Bar { pub let x: Int; init() { self.x = 1 } }`)},
+	}
+
+	state.Dependencies().AddOrUpdate(config.Dependency{Name: "Foo", Source: config.Source{NetworkName: "testnet", Address: first, ContractName: "Foo"}})
+	state.Dependencies().AddOrUpdate(config.Dependency{Name: "Baz", Source: config.Source{NetworkName: "testnet", Address: first, ContractName: "Baz"}})
+	state.Dependencies().AddOrUpdate(config.Dependency{Name: "OtherBar", Source: config.Source{NetworkName: "testnet", Address: third, ContractName: "Bar"}})
+
+	di := newTestInstaller(state, func(address flow.Address) map[string][]byte {
+		return contracts[address]
+	})
+
+	tree, err := di.Tree()
+	assert.NoError(t, err)
+
+	fooID := fmt.Sprintf("testnet://%s.Foo", first)
+	bazID := fmt.Sprintf("testnet://%s.Baz", first)
+	barID := fmt.Sprintf("testnet://%s.Bar", second)
+	otherBarID := fmt.Sprintf("testnet://%s.Bar", third)
+	quxID := fmt.Sprintf("testnet://%s.Qux", second)
+
+	assert.Equal(t, []string{bazID, fooID, otherBarID}, tree.Roots)
+	assert.Len(t, tree.Nodes, 5)
+	assert.ElementsMatch(t, []TreeEdge{
+		{From: bazID, To: barID},
+		{From: fooID, To: barID},
+		{From: fooID, To: quxID},
+	}, tree.Edges)
+
+	bar := tree.Node(barID)
+	assert.True(t, bar.Duplicate)
+	assert.True(t, bar.Conflict)
+	assert.True(t, tree.Node(otherBarID).Conflict)
+	assert.False(t, tree.Node(fooID).Duplicate)
+	assert.False(t, tree.Node(fooID).Conflict)
+	assert.Contains(t, tree.Node(quxID).Error, "contract not found")
+
+	text := (&treeResult{tree: tree}).String()
+	assert.Contains(t, text, fmt.Sprintf("%s (error: ", quxID))
+	assert.Contains(t, text, fmt.Sprintf("%s (duplicate, conflict)", barID))
+
+	dot := (&treeResult{tree: tree, dot: true}).String()
+	assert.Contains(t, dot, fmt.Sprintf("%q -> %q;", fooID, barID))
+	assert.Contains(t, dot, "color=red")
+}
+
+func TestDependencyInstallerExternal(t *testing.T) {
+
+	logger := output.NewStdoutLogger(output.NoneLog)
+
+	t.Run("File source", func(t *testing.T) {
+		af, _ := tests.ReaderWriter()
+		rw := util.NewConfigReaderWriter(&af, []string{config.DefaultPath})
+		state, err := flowkit.Init(rw, crypto.ECDSA_P256, crypto.SHA3_256)
+		assert.NoError(t, err)
+
+		_ = rw.WriteFile("shared/Foo.cdc", []byte("import Bar from \"./lib/Bar.cdc\"\nimport \"Baz\"\nimport \"Other\"\npub contract Foo {}"), 0644)
+		_ = rw.WriteFile("shared/lib/Bar.cdc", []byte(`pub contract Bar {}`), 0644)
+		_ = rw.WriteFile("shared/Baz.cdc", []byte(`pub contract Baz {}`), 0644)
+
+		di := &DependencyInstaller{Logger: logger, State: state}
+
+		err = di.Add("file://shared/Foo.cdc", "")
+		assert.NoError(t, err)
+
+		code, err := rw.ReadFile("imports/local/Foo.cdc")
+		assert.NoError(t, err)
+		assert.Equal(t, "import \"Bar\"\nimport \"Baz\"\nimport \"Other\"\npub contract Foo {}", string(code))
+
+		for name, location := range map[string]string{
+			"Foo": "imports/local/Foo.cdc",
+			"Bar": "imports/local/Bar.cdc",
+			"Baz": "imports/local/Baz.cdc",
+		} {
+			contract, err := state.Contracts().ByName(name)
+			assert.NoError(t, err)
+			assert.Equal(t, location, contract.Location)
+		}
+
+		lock, err := LoadLockfile(rw)
+		assert.NoError(t, err)
+		assert.Equal(t, "file://shared/lib/Bar.cdc", lock.ByName("Bar").Source)
+		assert.Empty(t, lock.ByName("Bar").Commit)
+		assert.Len(t, *state.Dependencies(), 0)
+
+		// saving the configuration keeps the external dependencies
+		assert.NoError(t, state.SaveDefault())
+		external, err := loadExternalDependencies(rw, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Bar", "Baz", "Foo"}, external.names())
+		assert.Equal(t, "file://shared/lib/Bar.cdc", external["Bar"].Source)
+		assert.Equal(t, lock.ByName("Bar").Hash, external["Bar"].Hash)
+
+		_ = rw.WriteFile("shared/lib/Bar.cdc", []byte(`pub contract Bar { pub let x: Int; init() { self.x = 1 } }`), 0644)
+		outdated, err := di.Outdated()
+		assert.NoError(t, err)
+		assert.Len(t, outdated, 1)
+		assert.Equal(t, "Bar", outdated[0].Name)
+		assert.Equal(t, "file://shared/lib/Bar.cdc", outdated[0].Source)
+
+		tree, err := di.Tree()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"file://shared/lib/Bar.cdc", "file://shared/Baz.cdc", "file://shared/Foo.cdc"}, tree.Roots)
+		assert.ElementsMatch(t, []TreeEdge{
+			{From: "file://shared/Foo.cdc", To: "file://shared/lib/Bar.cdc"},
+			{From: "file://shared/Foo.cdc", To: "file://shared/Baz.cdc"},
+		}, tree.Edges)
+		assert.True(t, tree.Node("file://shared/lib/Bar.cdc").Duplicate)
+
+		// a contract with the same name from another source would be installed to the same file
+		_ = rw.WriteFile("other/Baz.cdc", []byte(`pub contract Baz { pub let y: Int; init() { self.y = 2 } }`), 0644)
+		err = di.Add("file://other/Baz.cdc", "OtherBaz")
+		assert.ErrorContains(t, err, "OtherBaz from file://other/Baz.cdc would overwrite Baz from file://shared/Baz.cdc installed at imports/local/Baz.cdc")
+		code, err = rw.ReadFile("imports/local/Baz.cdc")
+		assert.NoError(t, err)
+		assert.Equal(t, `pub contract Baz {}`, string(code))
+
+		removed, err := di.Remove("Foo")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Bar", "Baz", "Foo"}, removed)
+
+		_, err = rw.ReadFile("imports/local/Bar.cdc")
+		assert.Error(t, err)
+		found, err := util.ReadConfigSection(rw, nil, externalDependenciesKey, &external)
+		assert.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("Configuration file flag", func(t *testing.T) {
+		af, _ := tests.ReaderWriter()
+		paths := []string{"other.json"}
+		rw := util.NewConfigReaderWriter(&af, paths)
+		state, err := flowkit.Init(rw, crypto.ECDSA_P256, crypto.SHA3_256)
+		assert.NoError(t, err)
+		assert.NoError(t, state.Save("other.json"))
+
+		_ = rw.WriteFile("shared/Foo.cdc", []byte(`pub contract Foo {}`), 0644)
+
+		di := &DependencyInstaller{Logger: logger, State: state, ConfigPaths: paths}
+		err = di.Add("file://shared/Foo.cdc", "")
+		assert.NoError(t, err)
+
+		external, err := loadExternalDependencies(rw, paths)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Foo"}, external.names())
+
+		_, err = rw.Stat(config.DefaultPath)
+		assert.Error(t, err, "the default configuration is not written")
+
+		di.ConfigPaths = []string{"other.json", "another.json"}
+		err = di.Add("file://shared/Foo.cdc", "")
+		assert.ErrorContains(t, err, "specifying multiple paths is not supported")
+	})
+
+	t.Run("Invalid git source", func(t *testing.T) {
+		_, state, _ := util.TestMocks(t)
+		di := &DependencyInstaller{Logger: logger, State: state}
+
+		err := di.Add("git+https://github.com/onflow/flow-ft.git", "")
+		assert.ErrorContains(t, err, "invalid git source")
+	})
+
+	t.Run("Git source", func(t *testing.T) {
+		_, state, rw := util.TestMocks(t)
+
+		dir := t.TempDir()
+		repo, err := git.PlainInit(dir, false)
+		assert.NoError(t, err)
+
+		commitFiles := func(files map[string]string) string {
+			worktree, err := repo.Worktree()
+			assert.NoError(t, err)
+			for name, content := range files {
+				assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+				_, err = worktree.Add(name)
+				assert.NoError(t, err)
+			}
+			hash, err := worktree.Commit("update", &git.CommitOptions{
+				Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+			})
+			assert.NoError(t, err)
+			return hash.String()
+		}
+
+		first := commitFiles(map[string]string{
+			"contracts/Foo.cdc": "import Bar from \"./Bar.cdc\"\npub contract Foo {}",
+			"contracts/Bar.cdc": `pub contract Bar {}`,
+		})
+
+		source := fmt.Sprintf("git+%s#master:contracts/Foo.cdc", dir)
+		newInstaller := func() *DependencyInstaller {
+			return &DependencyInstaller{Logger: logger, State: state}
+		}
+
+		err = newInstaller().Add(source, "")
+		assert.NoError(t, err)
+
+		lock, err := LoadLockfile(rw)
+		assert.NoError(t, err)
+		assert.Equal(t, source, lock.ByName("Foo").Source)
+		assert.Equal(t, first, lock.ByName("Foo").Commit)
+		assert.Equal(t, first, lock.ByName("Bar").Commit)
+
+		external, err := loadExternalDependencies(rw, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, ExternalDependency{Source: source, Commit: first, Hash: lock.ByName("Foo").Hash}, external["Foo"])
+
+		second := commitFiles(map[string]string{
+			"contracts/Bar.cdc": `pub contract Bar { pub let x: Int; init() { self.x = 1 } }`,
+		})
+
+		di := newInstaller()
+		di.Frozen = true
+		err = di.Install()
+		assert.NoError(t, err, "locked commit is installed")
+
+		err = newInstaller().Update(nil)
+		assert.NoError(t, err)
+
+		lock, err = LoadLockfile(rw)
+		assert.NoError(t, err)
+		assert.Equal(t, second, lock.ByName("Bar").Commit)
+
+		contract, err := state.Contracts().ByName("Bar")
+		assert.NoError(t, err)
+		code, err := rw.ReadFile(contract.Location)
+		assert.NoError(t, err)
+		assert.Contains(t, string(code), "self.x = 1")
+	})
+}
+
+func TestDependencyInstallerDryRun(t *testing.T) {
+
+	_, state, rw := util.TestMocks(t)
+
+	serviceAcc, _ := state.EmulatorServiceAccount()
+	serviceAddress := serviceAcc.Address
+
+	newInstaller := func(contractSource []byte) *DependencyInstaller {
+		return newTestInstaller(state, helloContract(contractSource))
+	}
+
+	sourceStr := fmt.Sprintf("emulator://%s.%s", serviceAddress.String(), tests.ContractHelloString.Name)
+	filePath := fmt.Sprintf("imports/%s/%s.cdc", serviceAddress.String(), tests.ContractHelloString.Name)
+
+	t.Run("Add", func(t *testing.T) {
+		di := newInstaller(tests.ContractHelloString.Source)
+		di.DryRun = true
+
+		err := di.Add(sourceStr, "")
+		assert.NoError(t, err)
+
+		plan := di.Plan()
+		assert.Equal(t, []PlannedFile{{Path: filePath, Created: true}}, plan.Files)
+		assert.Equal(t, []string{tests.ContractHelloString.Name}, plan.Dependencies)
+		assert.Equal(t, []string{tests.ContractHelloString.Name}, plan.Contracts)
+		assert.Empty(t, plan.Hashes)
+
+		_, err = rw.Stat(filePath)
+		assert.Error(t, err)
+		_, err = rw.Stat(LockfilePath)
+		assert.Error(t, err)
+	})
+
+	t.Run("Install changed", func(t *testing.T) {
+		_, state, rw = util.TestMocks(t)
+		err := newInstaller(tests.ContractHelloString.Source).Add(sourceStr, "")
+		assert.NoError(t, err)
+		oldHash := state.Dependencies().ByName(tests.ContractHelloString.Name).Hash
+
+		changed := append([]byte("// changed upstream\n"), tests.ContractHelloString.Source...)
+		di := newInstaller(changed)
+		di.DryRun = true
+
+		err = di.Install()
+		assert.NoError(t, err)
+
+		plan := di.Plan()
+		assert.Equal(t, []PlannedFile{{Path: filePath, Created: false}}, plan.Files)
+		assert.Empty(t, plan.Dependencies)
+		assert.Len(t, plan.Hashes, 1)
+		assert.Equal(t, oldHash, plan.Hashes[0].From)
+		assert.Equal(t, codeHash(changed), plan.Hashes[0].To)
+
+		code, err := rw.ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, tests.ContractHelloString.Source, code)
+	})
+}
+
+// newTestInstaller returns an installer for state whose gateways serve the
+// contracts returned by accountContracts for the requested account.
+func newTestInstaller(state *flowkit.State, accountContracts func(address flow.Address) map[string][]byte) *DependencyInstaller {
+	gw := mocks.DefaultMockGateway()
+	gw.GetAccount.Run(func(args mock.Arguments) {
+		addr := args.Get(0).(flow.Address)
+		acc := tests.NewAccountWithAddress(addr.String())
+		acc.Contracts = accountContracts(addr)
+
+		gw.GetAccount.Return(acc, nil)
+	})
+
+	return &DependencyInstaller{
+		Gateways: map[string]gateway.Gateway{
+			config.EmulatorNetwork.Name: gw.Mock,
+			config.TestnetNetwork.Name:  gw.Mock,
+			config.MainnetNetwork.Name:  gw.Mock,
+		},
+		Logger: output.NewStdoutLogger(output.NoneLog),
+		State:  state,
+	}
+}
+
+// helloContract serves source as the Hello contract on every account.
+func helloContract(source []byte) func(address flow.Address) map[string][]byte {
+	return func(flow.Address) map[string][]byte {
+		return map[string][]byte{tests.ContractHelloString.Name: source}
+	}
+}
@@ -0,0 +1,589 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dependencymanager
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/parser"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/config"
+	"github.com/onflow/flowkit/project"
+
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+const (
+	gitSourcePrefix  = "git+"
+	fileSourcePrefix = "file://"
+)
+
+// externalDependenciesKey is the flow.json section external dependencies are recorded in.
+const externalDependenciesKey = "externalDependencies"
+
+// ExternalDependency is a dependency installed from a git repository or a local file.
+//
+// The dependencies of flow.json only support on-chain sources, so external dependencies are recorded in
+// their own section of flow.json by name, including the contracts they import, and added as contracts.
+type ExternalDependency struct {
+	Source string `json:"source"`
+	Commit string `json:"commit,omitempty"`
+	Hash   string `json:"hash"`
+}
+
+// ExternalDependencies are the external dependencies of the project by name.
+type ExternalDependencies map[string]ExternalDependency
+
+// loadExternalDependencies reads the external dependencies from the configuration on the paths.
+func loadExternalDependencies(rw flowkit.ReaderWriter, paths []string) (ExternalDependencies, error) {
+	external := make(ExternalDependencies)
+	if _, err := util.ReadConfigSection(rw, paths, externalDependenciesKey, &external); err != nil {
+		return nil, err
+	}
+
+	return external, nil
+}
+
+// save writes the external dependencies to the configuration edited for the paths, the section is removed if there are none.
+func (e ExternalDependencies) save(rw flowkit.ReaderWriter, paths []string) error {
+	if len(e) == 0 {
+		return util.WriteConfigSection(rw, paths, externalDependenciesKey, nil)
+	}
+
+	return util.WriteConfigSection(rw, paths, externalDependenciesKey, e)
+}
+
+// names returns the names of the external dependencies in a deterministic order.
+func (e ExternalDependencies) names() []string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// externalSource is a dependency source that is not deployed on-chain, a contract file in a git repository
+// in the format git+https://host/repo.git#ref:path/Contract.cdc or on disk in the format file://path/Contract.cdc.
+type externalSource struct {
+	Repository string
+	Ref        string
+	Path       string
+}
+
+func isExternalSource(source string) bool {
+	return strings.HasPrefix(source, gitSourcePrefix) || strings.HasPrefix(source, fileSourcePrefix)
+}
+
+func parseExternalSource(source string) (externalSource, error) {
+	if strings.HasPrefix(source, fileSourcePrefix) {
+		p := strings.TrimPrefix(source, fileSourcePrefix)
+		if p == "" {
+			return externalSource{}, fmt.Errorf("invalid file source %s, expected file://path/Contract.cdc", source)
+		}
+		return externalSource{Path: filepath.Clean(p)}, nil
+	}
+
+	repository, location, ok := strings.Cut(strings.TrimPrefix(source, gitSourcePrefix), "#")
+	if !ok || repository == "" || location == "" {
+		return externalSource{}, fmt.Errorf("invalid git source %s, expected git+https://host/repo.git#ref:path/Contract.cdc", source)
+	}
+
+	ref, p, ok := strings.Cut(location, ":")
+	if !ok {
+		ref, p = "", location
+	}
+
+	return externalSource{
+		Repository: repository,
+		Ref:        ref,
+		Path:       path.Clean(p),
+	}, nil
+}
+
+func (s externalSource) isGit() bool {
+	return s.Repository != ""
+}
+
+func (s externalSource) String() string {
+	if !s.isGit() {
+		return fileSourcePrefix + filepath.ToSlash(s.Path)
+	}
+
+	if s.Ref == "" {
+		return fmt.Sprintf("%s%s#%s", gitSourcePrefix, s.Repository, s.Path)
+	}
+
+	return fmt.Sprintf("%s%s#%s:%s", gitSourcePrefix, s.Repository, s.Ref, s.Path)
+}
+
+// relative resolves a path imported by this source, imports stay in the same repository and ref.
+func (s externalSource) relative(importPath string) externalSource {
+	if s.isGit() {
+		s.Path = path.Join(path.Dir(s.Path), importPath)
+	} else {
+		s.Path = filepath.Join(filepath.Dir(s.Path), importPath)
+	}
+
+	return s
+}
+
+// installPath returns the path the contract from this source is installed to.
+func (s externalSource) installPath(contractName string) string {
+	dir := "local"
+	if s.isGit() {
+		dir = strings.TrimSuffix(s.Repository, ".git")
+		if u, err := url.Parse(s.Repository); err == nil {
+			dir = strings.TrimSuffix(u.Host+u.Path, ".git")
+		}
+	}
+
+	return filepath.Join("imports", filepath.FromSlash(strings.Trim(dir, "/")), fmt.Sprintf("%s.cdc", contractName))
+}
+
+// addExternal installs a contract from a git repository or local file, together with all the files it imports.
+func (di *DependencyInstaller) addExternal(source, customName string) error {
+	src, err := parseExternalSource(source)
+	if err != nil {
+		return err
+	}
+
+	if _, err := di.processExternal(src, customName, ""); err != nil {
+		return fmt.Errorf("error processing dependency: %w", err)
+	}
+
+	return di.save()
+}
+
+// installExternal reinstalls the external dependencies, limited to the provided names if any.
+//
+// Git dependencies are installed at their commit unless updating.
+func (di *DependencyInstaller) installExternal(names []string) error {
+	for _, name := range di.external.names() {
+		if len(names) > 0 && !slices.Contains(names, name) {
+			continue
+		}
+		external := di.external[name]
+
+		src, err := parseExternalSource(external.Source)
+		if err != nil {
+			return fmt.Errorf("error parsing source of %s: %w", name, err)
+		}
+
+		commit := di.externalCommit(name)
+		if di.update {
+			commit = ""
+		}
+
+		if _, err := di.processExternal(src, name, commit); err != nil {
+			return fmt.Errorf("error processing dependency %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// externalCommit returns the commit the external dependency is installed at, from the lockfile or from flow.json if not locked.
+func (di *DependencyInstaller) externalCommit(name string) string {
+	external := di.external[name]
+	if locked := di.lockfile.ByName(name); locked != nil && locked.Source == external.Source {
+		return locked.Commit
+	}
+
+	return external.Commit
+}
+
+// processExternal installs the contract from the source and returns the name it was installed as.
+//
+// Relative path imports, like import Foo from "./Foo.cdc", and string imports of contracts next to the file,
+// like import "Foo", are installed recursively and rewritten to import the installed contracts by name.
+// The commit is the revision to read from git sources, if empty the ref of the source is resolved.
+// Every installed contract is recorded as an external dependency and in the lockfile.
+func (di *DependencyInstaller) processExternal(src externalSource, assignedName, commit string) (string, error) {
+	if name, ok := di.externals[src.String()]; ok {
+		return name, nil
+	}
+
+	code, commit, err := di.readExternal(src, commit)
+	if err != nil {
+		return "", err
+	}
+
+	program, err := project.NewProgram(code, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse program %s: %w", src, err)
+	}
+
+	contractName, err := program.Name()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse contract name %s: %w", src, err)
+	}
+
+	if assignedName == "" {
+		assignedName = contractName
+	}
+	di.externals[src.String()] = assignedName
+
+	installedCode, err := di.resolveExternalImports(src, code, commit)
+	if err != nil {
+		return "", err
+	}
+
+	installPath := src.installPath(contractName)
+	if owner := di.externalInstalledAt(installPath, src); owner != "" && owner != assignedName {
+		return "", fmt.Errorf(
+			"%s from %s would overwrite %s from %s installed at %s, contracts from different sources must have different names",
+			assignedName, src, owner, di.external[owner].Source, filepath.ToSlash(installPath),
+		)
+	}
+
+	if !di.resolveExternalConflict(src, assignedName, installPath) {
+		return assignedName, nil
+	}
+
+	hash := codeHash(code)
+	if di.Frozen {
+		if err := di.verifyLockedExternal(src, assignedName, hash, commit); err != nil {
+			return "", err
+		}
+	}
+
+	locked := di.lockfile.ByName(assignedName)
+	changed := locked != nil && locked.Hash != hash
+	if changed && di.DryRun {
+		di.plan.addHash(assignedName, locked.Hash, hash)
+	} else if changed && !di.Frozen && !di.update {
+		msg := fmt.Sprintf("The latest version of %s is different from the one you have locally. Do you want to update it?", assignedName)
+		if !util.GenericBoolPrompt(msg) {
+			return assignedName, nil
+		}
+	}
+
+	if err := di.writeExternal(installPath, installedCode, changed); err != nil {
+		return "", err
+	}
+
+	if !di.DryRun {
+		di.Logger.Info(fmt.Sprintf("Dependency Manager: %s from %s installed", assignedName, src))
+	}
+
+	di.State.Contracts().AddOrUpdate(config.Contract{
+		Name:     assignedName,
+		Location: filepath.ToSlash(installPath),
+	})
+
+	di.lockfile.AddOrUpdate(assignedName, LockedDependency{
+		Source: src.String(),
+		Hash:   hash,
+		Commit: commit,
+	})
+
+	di.external[assignedName] = ExternalDependency{
+		Source: src.String(),
+		Commit: commit,
+		Hash:   hash,
+	}
+
+	return assignedName, nil
+}
+
+// externalImport is an import of a file that is part of the same external source.
+type externalImport struct {
+	declaration *ast.ImportDeclaration
+	src         externalSource
+}
+
+// externalImports returns the imports of the code that are resolved from the source.
+//
+// Relative path imports, like import Foo from "./Foo.cdc", and string imports of contracts next to the file,
+// like import "Foo", are part of the source. Other imports have to be provided by the project.
+func (di *DependencyInstaller) externalImports(src externalSource, code []byte, commit string) ([]externalImport, error) {
+	parsed, err := parser.ParseProgram(nil, code, parser.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse program %s: %w", src, err)
+	}
+
+	imports := make([]externalImport, 0)
+	for _, imp := range parsed.ImportDeclarations() {
+		location, ok := imp.Location.(common.StringLocation)
+		if !ok {
+			continue // address and identifier imports are resolved by the project
+		}
+
+		importPath := string(location)
+		if !strings.HasSuffix(importPath, ".cdc") {
+			sibling := src.relative(fmt.Sprintf("%s.cdc", importPath))
+			if _, _, err := di.readExternal(sibling, commit); err != nil {
+				continue // not part of the source, so it has to be provided by the project
+			}
+			importPath = fmt.Sprintf("%s.cdc", importPath)
+		}
+
+		imports = append(imports, externalImport{declaration: imp, src: src.relative(importPath)})
+	}
+
+	return imports, nil
+}
+
+// resolveExternalImports installs the files imported by the code and rewrites the imports to use the installed names.
+func (di *DependencyInstaller) resolveExternalImports(src externalSource, code []byte, commit string) ([]byte, error) {
+	imports, err := di.externalImports(src, code, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	offset := 0
+
+	for _, imp := range imports {
+		name, err := di.processExternal(imp.src, "", commit)
+		if err != nil {
+			return nil, fmt.Errorf("error installing import %s of %s: %w", imp.declaration.Location, src, err)
+		}
+
+		b.Write(code[offset:imp.declaration.StartPos.Offset])
+		b.WriteString(fmt.Sprintf("import %q", name))
+		offset = imp.declaration.EndPos.Offset + 1
+	}
+	b.Write(code[offset:])
+
+	return []byte(b.String()), nil
+}
+
+// readExternal reads the contract code from the source and returns it with the commit it was read at.
+func (di *DependencyInstaller) readExternal(src externalSource, commit string) ([]byte, string, error) {
+	if !src.isGit() {
+		code, err := di.State.ReaderWriter().ReadFile(src.Path)
+		if err != nil {
+			return nil, "", fmt.Errorf("error reading %s: %w", src, err)
+		}
+		return code, "", nil
+	}
+
+	if di.Offline {
+		return nil, "", fmt.Errorf("%s can't be installed offline", src)
+	}
+
+	repo, err := di.repository(src.Repository)
+	if err != nil {
+		return nil, "", err
+	}
+
+	revision := commit
+	if revision == "" {
+		revision = src.Ref
+	}
+	if revision == "" {
+		revision = string(plumbing.HEAD)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, "", fmt.Errorf("could not resolve %s in %s: %w", revision, src.Repository, err)
+	}
+
+	c, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read commit %s in %s: %w", hash, src.Repository, err)
+	}
+
+	file, err := c.File(src.Path)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read %s at %s: %w", src.Path, hash, err)
+	}
+
+	code, err := file.Contents()
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read %s at %s: %w", src.Path, hash, err)
+	}
+
+	return []byte(code), hash.String(), nil
+}
+
+// repository clones the git repository into memory, each repository is only cloned once per installation.
+func (di *DependencyInstaller) repository(repositoryURL string) (*git.Repository, error) {
+	if repo, ok := di.repositories[repositoryURL]; ok {
+		return repo, nil
+	}
+
+	di.Logger.Info(fmt.Sprintf("Dependency Manager: cloning %s", repositoryURL))
+
+	repo, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+		URL:    repositoryURL,
+		Mirror: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not clone %s: %w", repositoryURL, err)
+	}
+
+	di.repositories[repositoryURL] = repo
+
+	return repo, nil
+}
+
+// outdatedExternal compares the external dependencies against the latest code of their source.
+//
+// Git dependencies are compared with the code at the recorded commit and otherwise with the installed file.
+func (di *DependencyInstaller) outdatedExternal() ([]OutdatedDependency, error) {
+	outdated := make([]OutdatedDependency, 0)
+
+	for _, name := range di.external.names() {
+		external := di.external[name]
+
+		src, err := parseExternalSource(external.Source)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing source of %s: %w", name, err)
+		}
+
+		code, _, err := di.readExternal(src, "")
+		if err != nil {
+			return nil, err
+		}
+
+		hash := codeHash(code)
+		if hash == external.Hash {
+			continue
+		}
+
+		// a missing local file is shown as a diff against empty code
+		var localCode []byte
+		if src.isGit() && external.Commit != "" {
+			localCode, _, _ = di.readExternal(src, external.Commit)
+		} else if contract, err := di.State.Contracts().ByName(name); err == nil {
+			localCode, _ = di.State.ReaderWriter().ReadFile(contract.Location)
+		}
+
+		outdated = append(outdated, OutdatedDependency{
+			Name:       name,
+			Source:     external.Source,
+			LocalHash:  external.Hash,
+			RemoteHash: hash,
+			Diff:       util.ContractDiff(localCode, code),
+		})
+	}
+
+	return outdated, nil
+}
+
+// externalInstalledAt returns the name of the external dependency from another source installed at the path, if any.
+//
+// Contracts from external sources are installed by their contract name, so two sources of contracts
+// with the same name would be installed to the same file.
+func (di *DependencyInstaller) externalInstalledAt(installPath string, src externalSource) string {
+	for _, name := range di.external.names() {
+		if di.external[name].Source == src.String() {
+			continue
+		}
+
+		contract, err := di.State.Contracts().ByName(name)
+		if err == nil && contract.Location == filepath.ToSlash(installPath) {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// resolveExternalConflict checks the name isn't already used by an on-chain dependency or another contract.
+//
+// External dependencies can't be renamed since their imports are resolved by name, so the rename strategy fails.
+func (di *DependencyInstaller) resolveExternalConflict(src externalSource, assignedName, installPath string) bool {
+	existing := ""
+	if dependency := di.State.Dependencies().ByName(assignedName); dependency != nil {
+		existing = sourceString(dependency.Source.NetworkName, dependency.Source.Address.String(), dependency.Source.ContractName)
+	} else if external, ok := di.external[assignedName]; ok && external.Source != src.String() {
+		existing = external.Source
+	} else if contract, err := di.State.Contracts().ByName(assignedName); err == nil && !ok && contract.Location != filepath.ToSlash(installPath) {
+		existing = contract.Location
+	}
+
+	if existing == "" {
+		return true
+	}
+
+	conflict := DependencyConflict{
+		Name:           assignedName,
+		ExistingSource: existing,
+		NewSource:      src.String(),
+	}
+
+	switch di.OnConflict {
+	case ConflictKeep:
+		di.Logger.Info(fmt.Sprintf("Dependency Manager: keeping %s from %s, skipping %s", conflict.Name, conflict.ExistingSource, conflict.NewSource))
+		return false
+	case ConflictReplace:
+		di.Logger.Info(fmt.Sprintf("Dependency Manager: replacing %s from %s with %s", conflict.Name, conflict.ExistingSource, conflict.NewSource))
+		return true
+	default:
+		di.conflicts = append(di.conflicts, conflict)
+		return false
+	}
+}
+
+func (di *DependencyInstaller) verifyLockedExternal(src externalSource, assignedName, hash, commit string) error {
+	locked := di.lockfile.ByName(assignedName)
+	if locked == nil {
+		return fmt.Errorf("dependency %s is not recorded in %s", assignedName, LockfilePath)
+	}
+
+	if locked.Source != src.String() {
+		return fmt.Errorf("dependency %s source %s does not match locked source %s", assignedName, src, locked.Source)
+	}
+
+	if locked.Hash != hash || locked.Commit != commit {
+		return fmt.Errorf("dependency %s changed since it was locked: locked hash %s, current hash %s", assignedName, locked.Hash, hash)
+	}
+
+	return nil
+}
+
+func (di *DependencyInstaller) writeExternal(installPath string, code []byte, overwrite bool) error {
+	if di.DryRun {
+		di.plan.addFile(di.State.ReaderWriter(), installPath, code)
+		return nil
+	}
+
+	if _, err := di.State.ReaderWriter().Stat(installPath); err == nil && !overwrite {
+		return nil
+	}
+
+	if err := di.State.ReaderWriter().MkdirAll(filepath.Dir(installPath), 0755); err != nil {
+		return fmt.Errorf("error creating directories: %w", err)
+	}
+
+	if err := di.State.ReaderWriter().WriteFile(installPath, code, 0644); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,357 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dependencymanager
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/onflow/cadence/runtime/parser"
+	flowsdk "github.com/onflow/flow-go-sdk"
+
+	"github.com/onflow/flowkit/config"
+	"github.com/onflow/flowkit/project"
+)
+
+// importGraph maps a dependency name to the names of the dependencies it imports.
+type importGraph map[string][]string
+
+// importedContracts returns the names of all contracts imported by the code.
+//
+// Both address imports (import Foo from 0x01) and the string imports the dependency manager
+// converts them to (import "Foo") are supported.
+func importedContracts(code []byte) ([]string, error) {
+	program, err := parser.ParseProgram(nil, code, parser.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for _, imp := range program.ImportDeclarations() {
+		if len(imp.Identifiers) > 0 {
+			for _, identifier := range imp.Identifiers {
+				names = append(names, identifier.Identifier)
+			}
+			continue
+		}
+
+		names = append(names, imp.Location.String())
+	}
+
+	return names, nil
+}
+
+// dependencyByContractName finds a dependency by its name or by the name of its source contract.
+func dependencyByContractName(dependencies *config.Dependencies, name string) *config.Dependency {
+	if dependency := dependencies.ByName(name); dependency != nil {
+		return dependency
+	}
+
+	for i, dependency := range *dependencies {
+		if dependency.Source.ContractName == name {
+			return &(*dependencies)[i]
+		}
+	}
+
+	return nil
+}
+
+// isDependency returns whether the name is an on-chain or external dependency.
+func (di *DependencyInstaller) isDependency(name string) bool {
+	_, ok := di.external[name]
+	return ok || di.State.Dependencies().ByName(name) != nil
+}
+
+// importedDependency returns the name of the dependency a contract import resolves to.
+//
+// Installed external contracts import each other by their installed names, so they are only found by name.
+func (di *DependencyInstaller) importedDependency(name string) (string, bool) {
+	if dependency := dependencyByContractName(di.State.Dependencies(), name); dependency != nil {
+		return dependency.Name, true
+	}
+
+	if _, ok := di.external[name]; ok {
+		return name, true
+	}
+
+	return "", false
+}
+
+// installedImportGraph builds the import graph of all dependencies from the contract files installed in the project.
+func (di *DependencyInstaller) installedImportGraph() importGraph {
+	dependencies := di.State.Dependencies()
+	graph := make(importGraph, len(*dependencies)+len(di.external))
+
+	paths := make(map[string]string)
+	for _, dependency := range *dependencies {
+		paths[dependency.Name] = contractFilePath(dependency.Source.Address.String(), dependency.Source.ContractName)
+	}
+	for name := range di.external {
+		paths[name] = ""
+		if contract, err := di.State.Contracts().ByName(name); err == nil {
+			paths[name] = contract.Location
+		}
+	}
+
+	for name, path := range paths {
+		graph[name] = make([]string, 0)
+
+		code, err := di.State.ReaderWriter().ReadFile(path)
+		if err != nil {
+			continue // not installed, so it can't import anything
+		}
+
+		imports, err := importedContracts(code)
+		if err != nil {
+			continue
+		}
+
+		for _, imported := range imports {
+			if dependency, ok := di.importedDependency(imported); ok {
+				graph[name] = append(graph[name], dependency)
+			}
+		}
+	}
+
+	return graph
+}
+
+// reachable returns all the names reachable from the roots, including the roots, never traversing through the skipped name.
+func (g importGraph) reachable(roots []string, skip string) map[string]bool {
+	visited := make(map[string]bool)
+
+	var visit func(name string)
+	visit = func(name string) {
+		if name == skip || visited[name] {
+			return
+		}
+		visited[name] = true
+
+		for _, imported := range g[name] {
+			visit(imported)
+		}
+	}
+
+	for _, root := range roots {
+		visit(root)
+	}
+
+	return visited
+}
+
+// sortedNames returns the names of the set in a deterministic order.
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// TreeNode is a contract in the dependency tree, identified by its source.
+//
+// The network and address are only set for on-chain sources.
+type TreeNode struct {
+	ID        string `json:"id"`
+	Network   string `json:"network,omitempty"`
+	Address   string `json:"address,omitempty"`
+	Contract  string `json:"contract"`
+	Duplicate bool   `json:"duplicate"`
+	Conflict  bool   `json:"conflict"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TreeEdge is an import of one contract by another.
+type TreeEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DependencyTree is the transitive import graph of the project dependencies as resolved from their sources.
+type DependencyTree struct {
+	Roots []string   `json:"roots"`
+	Nodes []TreeNode `json:"nodes"`
+	Edges []TreeEdge `json:"edges"`
+}
+
+// Node returns the node with the provided ID or nil if it is not part of the tree.
+func (t *DependencyTree) Node(id string) *TreeNode {
+	for i := range t.Nodes {
+		if t.Nodes[i].ID == id {
+			return &t.Nodes[i]
+		}
+	}
+
+	return nil
+}
+
+// Imports returns the IDs of the nodes imported by the node with the provided ID.
+func (t *DependencyTree) Imports(id string) []string {
+	imports := make([]string, 0)
+	for _, edge := range t.Edges {
+		if edge.From == id {
+			imports = append(imports, edge.To)
+		}
+	}
+
+	return imports
+}
+
+// Tree resolves the import graph of all dependencies the same way an installation would, without changing the project.
+//
+// External dependencies are resolved at the commit they are installed at.
+//
+// Contracts that fail to resolve are part of the tree with an error, so it's visible what imports them.
+// A contract imported from more than one place is marked as duplicate and different sources
+// for the same contract name are marked as conflicting.
+func (di *DependencyInstaller) Tree() (*DependencyTree, error) {
+	if err := di.start(); err != nil {
+		return nil, err
+	}
+	di.readOnly = true
+
+	tree := &DependencyTree{
+		Roots: make([]string, 0),
+		Nodes: make([]TreeNode, 0),
+		Edges: make([]TreeEdge, 0),
+	}
+	importers := make(map[string]int)
+
+	var visit func(networkName string, address flowsdk.Address, contractName string) string
+	visit = func(networkName string, address flowsdk.Address, contractName string) string {
+		id := sourceString(networkName, address.String(), contractName)
+		if tree.Node(id) != nil {
+			return id
+		}
+
+		node := TreeNode{
+			ID:       id,
+			Network:  networkName,
+			Address:  address.String(),
+			Contract: contractName,
+		}
+
+		program, err := di.fetchContract(networkName, address, contractName)
+		if err != nil {
+			node.Error = err.Error()
+			tree.Nodes = append(tree.Nodes, node)
+			return id
+		}
+		tree.Nodes = append(tree.Nodes, node)
+
+		if !program.HasAddressImports() {
+			return id
+		}
+
+		for _, imp := range program.AddressImportDeclarations() {
+			imported := visit(networkName, flowsdk.HexToAddress(imp.Location.String()), imp.Identifiers[0].String())
+			tree.Edges = append(tree.Edges, TreeEdge{From: id, To: imported})
+			importers[imported]++
+		}
+
+		return id
+	}
+
+	var visitExternal func(src externalSource, commit string) string
+	visitExternal = func(src externalSource, commit string) string {
+		id := src.String()
+		if tree.Node(id) != nil {
+			return id
+		}
+
+		node := TreeNode{
+			ID:       id,
+			Contract: strings.TrimSuffix(filepath.Base(src.Path), ".cdc"),
+		}
+
+		code, _, err := di.readExternal(src, commit)
+		if err != nil {
+			node.Error = err.Error()
+			tree.Nodes = append(tree.Nodes, node)
+			return id
+		}
+
+		if program, err := project.NewProgram(code, nil, ""); err == nil {
+			if name, err := program.Name(); err == nil {
+				node.Contract = name
+			}
+		}
+
+		imports, err := di.externalImports(src, code, commit)
+		if err != nil {
+			node.Error = err.Error()
+			tree.Nodes = append(tree.Nodes, node)
+			return id
+		}
+		tree.Nodes = append(tree.Nodes, node)
+
+		for _, imp := range imports {
+			imported := visitExternal(imp.src, commit)
+			tree.Edges = append(tree.Edges, TreeEdge{From: id, To: imported})
+			importers[imported]++
+		}
+
+		return id
+	}
+
+	dependencies := append(config.Dependencies{}, *di.State.Dependencies()...)
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].Name < dependencies[j].Name
+	})
+
+	for _, dependency := range dependencies {
+		id := visit(dependency.Source.NetworkName, dependency.Source.Address, dependency.Source.ContractName)
+		tree.Roots = append(tree.Roots, id)
+		importers[id]++
+	}
+
+	for _, name := range di.external.names() {
+		src, err := parseExternalSource(di.external[name].Source)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing source of %s: %w", name, err)
+		}
+
+		id := visitExternal(src, di.externalCommit(name))
+		tree.Roots = append(tree.Roots, id)
+		importers[id]++
+	}
+
+	sources := make(map[string]map[string]bool)
+	for _, node := range tree.Nodes {
+		if sources[node.Contract] == nil {
+			sources[node.Contract] = make(map[string]bool)
+		}
+		sources[node.Contract][node.ID] = true
+	}
+
+	for i, node := range tree.Nodes {
+		tree.Nodes[i].Duplicate = importers[node.ID] > 1
+		tree.Nodes[i].Conflict = len(sources[node.Contract]) > 1
+	}
+
+	sort.Slice(tree.Nodes, func(i, j int) bool {
+		return tree.Nodes[i].ID < tree.Nodes[j].ID
+	})
+
+	return tree, nil
+}
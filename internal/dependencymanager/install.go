@@ -29,7 +29,12 @@ import (
 	"github.com/onflow/flow-cli/internal/command"
 )
 
-type installFlagsCollection struct{}
+type installFlagsCollection struct {
+	Frozen     bool   `default:"false" flag:"frozen" info:"Fail if any dependency differs from the versions pinned in flow.lock"`
+	OnConflict string `default:"fail" flag:"on-conflict" info:"How to resolve dependencies with the same name but a different source: fail, keep, replace or rename"`
+	Offline    bool   `default:"false" flag:"offline" info:"Install from the local dependency cache without accessing the network"`
+	DryRun     bool   `default:"false" flag:"dry-run" info:"Report the files and flow.json entries the installation would change without writing them"`
+}
 
 var installFlags = installFlagsCollection{}
 
@@ -45,24 +50,33 @@ var installCommand = &command.Command{
 
 func install(
 	_ []string,
-	_ command.GlobalFlags,
+	globalFlags command.GlobalFlags,
 	logger output.Logger,
 	flow flowkit.Services,
 	state *flowkit.State,
 ) (result command.Result, err error) {
 	logger.Info("🔄 Installing dependencies from flow.json...")
 
-	installer, err := NewDependencyInstaller(logger, state)
+	installer, err := NewDependencyInstaller(logger, state, flow)
 	if err != nil {
 		logger.Error(fmt.Sprintf("Error: %v", err))
 		return nil, err
 	}
+	installer.ConfigPaths = globalFlags.ConfigPaths
+	installer.Frozen = installFlags.Frozen
+	installer.OnConflict = installFlags.OnConflict
+	installer.Offline = installFlags.Offline
+	installer.DryRun = installFlags.DryRun
 
 	if err := installer.Install(); err != nil {
 		logger.Error(fmt.Sprintf("Error: %v", err))
 		return nil, err
 	}
 
+	if installer.DryRun {
+		return &planResult{plan: installer.Plan()}, nil
+	}
+
 	logger.Info("✅  Dependency installation complete. Check your flow.json")
 
 	return nil, nil
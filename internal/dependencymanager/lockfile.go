@@ -0,0 +1,118 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dependencymanager
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/onflow/flowkit"
+)
+
+// LockfilePath is the location of the lockfile relative to the project root.
+const LockfilePath = "flow.lock"
+
+const lockfileVersion = 1
+
+// LockedDependency pins a dependency to the exact code that was installed, git dependencies also record the commit.
+type LockedDependency struct {
+	Source      string `json:"source"`
+	Hash        string `json:"hash"`
+	BlockHeight uint64 `json:"blockHeight"`
+	Commit      string `json:"commit,omitempty"`
+}
+
+// Lockfile records every installed dependency, including transitive ones, by name.
+type Lockfile struct {
+	Version      int                         `json:"version"`
+	Dependencies map[string]LockedDependency `json:"dependencies"`
+}
+
+func newLockfile() *Lockfile {
+	return &Lockfile{
+		Version:      lockfileVersion,
+		Dependencies: make(map[string]LockedDependency),
+	}
+}
+
+// LoadLockfile reads the lockfile from the project root, an empty lockfile is returned if none exists yet.
+func LoadLockfile(rw flowkit.ReaderWriter) (*Lockfile, error) {
+	if _, err := rw.Stat(LockfilePath); err != nil {
+		return newLockfile(), nil
+	}
+
+	data, err := rw.ReadFile(LockfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading lockfile: %w", err)
+	}
+
+	lock := newLockfile()
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("error parsing lockfile: %w", err)
+	}
+
+	if lock.Version != lockfileVersion {
+		return nil, fmt.Errorf("unsupported lockfile version %d", lock.Version)
+	}
+
+	if lock.Dependencies == nil {
+		lock.Dependencies = make(map[string]LockedDependency)
+	}
+
+	return lock, nil
+}
+
+// Save writes the lockfile to the project root.
+func (l *Lockfile) Save(rw flowkit.ReaderWriter) error {
+	data, err := json.MarshalIndent(l, "", "\t")
+	if err != nil {
+		return fmt.Errorf("error serializing lockfile: %w", err)
+	}
+
+	if err := rw.WriteFile(LockfilePath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing lockfile: %w", err)
+	}
+
+	return nil
+}
+
+// ByName returns the locked dependency with the provided name or nil if it is not locked.
+func (l *Lockfile) ByName(name string) *LockedDependency {
+	dep, ok := l.Dependencies[name]
+	if !ok {
+		return nil
+	}
+
+	return &dep
+}
+
+// AddOrUpdate pins the dependency to the provided hash and block height.
+func (l *Lockfile) AddOrUpdate(name string, dep LockedDependency) {
+	l.Dependencies[name] = dep
+}
+
+// Remove unpins the dependency with the provided name.
+func (l *Lockfile) Remove(name string) {
+	delete(l.Dependencies, name)
+}
+
+// sourceString formats a source in the same form accepted by config.ParseSourceString.
+func sourceString(networkName, address, contractName string) string {
+	return fmt.Sprintf("%s://%s.%s", networkName, address, contractName)
+}
@@ -0,0 +1,124 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dependencymanager
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/output"
+
+	"github.com/onflow/flow-cli/internal/command"
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+type outdatedFlagsCollection struct{}
+
+var outdatedFlags = outdatedFlagsCollection{}
+
+var outdatedCommand = &command.Command{
+	Cmd: &cobra.Command{
+		Use:     "outdated",
+		Short:   "List dependencies whose code changed at their source since they were installed.",
+		Example: "flow dependencies outdated",
+		Args:    cobra.NoArgs,
+	},
+	Flags: &outdatedFlags,
+	RunS:  outdated,
+}
+
+func outdated(
+	_ []string,
+	globalFlags command.GlobalFlags,
+	logger output.Logger,
+	flow flowkit.Services,
+	state *flowkit.State,
+) (command.Result, error) {
+	logger.StartProgress("Checking dependencies for changes...")
+	defer logger.StopProgress()
+
+	installer, err := NewDependencyInstaller(logger, state, flow)
+	if err != nil {
+		return nil, err
+	}
+	installer.ConfigPaths = globalFlags.ConfigPaths
+
+	deps, err := installer.Outdated()
+	if err != nil {
+		return nil, err
+	}
+
+	return &outdatedResult{dependencies: deps}, nil
+}
+
+type outdatedResult struct {
+	dependencies []OutdatedDependency
+}
+
+var _ command.Result = &outdatedResult{}
+
+func (r *outdatedResult) JSON() any {
+	result := make([]map[string]string, 0, len(r.dependencies))
+	for _, dep := range r.dependencies {
+		result = append(result, map[string]string{
+			"name":       dep.Name,
+			"source":     dep.Source,
+			"localHash":  dep.LocalHash,
+			"remoteHash": dep.RemoteHash,
+		})
+	}
+
+	return result
+}
+
+func (r *outdatedResult) String() string {
+	if len(r.dependencies) == 0 {
+		return "All dependencies are up to date."
+	}
+
+	var b bytes.Buffer
+	writer := util.CreateTabWriter(&b)
+
+	for _, dep := range r.dependencies {
+		_, _ = fmt.Fprintf(writer, "Name\t %s\n", output.Bold(dep.Name))
+		_, _ = fmt.Fprintf(writer, "Source\t %s\n", dep.Source)
+		_, _ = fmt.Fprintf(writer, "Local Hash\t %s\n", dep.LocalHash)
+		_, _ = fmt.Fprintf(writer, "Remote Hash\t %s\n", dep.RemoteHash)
+		_ = writer.Flush()
+
+		b.WriteString(fmt.Sprintf("\n%s\n\n", dep.Diff))
+	}
+
+	b.WriteString("Run 'flow dependencies update' to install the latest versions.")
+
+	return b.String()
+}
+
+func (r *outdatedResult) Oneliner() string {
+	names := make([]string, 0, len(r.dependencies))
+	for _, dep := range r.dependencies {
+		names = append(names, dep.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,183 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dependencymanager
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/output"
+
+	"github.com/onflow/flow-cli/internal/command"
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+// PlannedFile is a contract file an installation would create or change.
+type PlannedFile struct {
+	Path    string `json:"path"`
+	Created bool   `json:"created"`
+}
+
+// HashChange is an installed dependency whose code would change.
+type HashChange struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Plan collects the changes an installation would make in dry-run mode.
+type Plan struct {
+	Files        []PlannedFile `json:"files"`
+	Dependencies []string      `json:"dependencies"`
+	Contracts    []string      `json:"contracts"`
+	Hashes       []HashChange  `json:"hashes"`
+
+	existingDependencies map[string]bool
+	existingContracts    map[string]bool
+}
+
+func newPlan(state *flowkit.State, external ExternalDependencies) *Plan {
+	plan := &Plan{
+		Files:                make([]PlannedFile, 0),
+		Dependencies:         make([]string, 0),
+		Contracts:            make([]string, 0),
+		Hashes:               make([]HashChange, 0),
+		existingDependencies: make(map[string]bool),
+		existingContracts:    make(map[string]bool),
+	}
+
+	for _, dependency := range *state.Dependencies() {
+		plan.existingDependencies[dependency.Name] = true
+	}
+	for name := range external {
+		plan.existingDependencies[name] = true
+	}
+	for _, contract := range *state.Contracts() {
+		plan.existingContracts[contract.Name] = true
+	}
+
+	return plan
+}
+
+// addFile records the file if writing the data would create or change it.
+func (p *Plan) addFile(rw flowkit.ReaderWriter, path string, data []byte) {
+	existing, err := rw.ReadFile(path)
+	if err == nil && bytes.Equal(existing, data) {
+		return
+	}
+
+	p.Files = append(p.Files, PlannedFile{Path: path, Created: err != nil})
+}
+
+// addHash records a change of the code installed for the dependency.
+func (p *Plan) addHash(name, from, to string) {
+	p.Hashes = append(p.Hashes, HashChange{Name: name, From: from, To: to})
+}
+
+// finish records the flow.json entries added to the state and the external dependencies and sorts the plan.
+func (p *Plan) finish(state *flowkit.State, external ExternalDependencies) {
+	for _, dependency := range *state.Dependencies() {
+		if !p.existingDependencies[dependency.Name] {
+			p.Dependencies = append(p.Dependencies, dependency.Name)
+		}
+	}
+	for name := range external {
+		if !p.existingDependencies[name] {
+			p.Dependencies = append(p.Dependencies, name)
+		}
+	}
+	for _, contract := range *state.Contracts() {
+		if !p.existingContracts[contract.Name] {
+			p.Contracts = append(p.Contracts, contract.Name)
+		}
+	}
+
+	sort.Slice(p.Files, func(i, j int) bool { return p.Files[i].Path < p.Files[j].Path })
+	sort.Slice(p.Hashes, func(i, j int) bool { return p.Hashes[i].Name < p.Hashes[j].Name })
+	sort.Strings(p.Dependencies)
+	sort.Strings(p.Contracts)
+}
+
+func (p *Plan) empty() bool {
+	return len(p.Files) == 0 && len(p.Dependencies) == 0 && len(p.Contracts) == 0 && len(p.Hashes) == 0
+}
+
+type planResult struct {
+	plan *Plan
+}
+
+var _ command.Result = &planResult{}
+
+func (r *planResult) JSON() any {
+	return r.plan
+}
+
+func (r *planResult) String() string {
+	if r.plan.empty() {
+		return "Dry run: no changes, all dependencies are installed."
+	}
+
+	var b bytes.Buffer
+	b.WriteString("Dry run, no changes were written.\n")
+
+	if len(r.plan.Files) > 0 {
+		b.WriteString(fmt.Sprintf("\n%s\n", output.Bold("Files")))
+		for _, file := range r.plan.Files {
+			action := "change"
+			if file.Created {
+				action = "create"
+			}
+			b.WriteString(fmt.Sprintf("  %s\t%s\n", action, file.Path))
+		}
+	}
+
+	if len(r.plan.Dependencies) > 0 || len(r.plan.Contracts) > 0 {
+		b.WriteString(fmt.Sprintf("\n%s\n", output.Bold("flow.json")))
+		writer := util.CreateTabWriter(&b)
+		if len(r.plan.Dependencies) > 0 {
+			_, _ = fmt.Fprintf(writer, "  Dependencies\t %s\n", strings.Join(r.plan.Dependencies, ", "))
+		}
+		if len(r.plan.Contracts) > 0 {
+			_, _ = fmt.Fprintf(writer, "  Contracts\t %s\n", strings.Join(r.plan.Contracts, ", "))
+		}
+		_ = writer.Flush()
+	}
+
+	if len(r.plan.Hashes) > 0 {
+		b.WriteString(fmt.Sprintf("\n%s\n", output.Bold("Changed hashes")))
+		for _, hash := range r.plan.Hashes {
+			b.WriteString(fmt.Sprintf("  %s\t%s -> %s\n", hash.Name, hash.From, hash.To))
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (r *planResult) Oneliner() string {
+	return fmt.Sprintf(
+		"%d files, %d dependencies, %d contracts, %d changed hashes",
+		len(r.plan.Files),
+		len(r.plan.Dependencies),
+		len(r.plan.Contracts),
+		len(r.plan.Hashes),
+	)
+}
@@ -0,0 +1,76 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dependencymanager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/output"
+
+	"github.com/onflow/flow-cli/internal/command"
+)
+
+type removeFlagsCollection struct {
+	Force bool `default:"false" flag:"force" info:"Remove the dependency even if it is still imported, without confirmation"`
+}
+
+var removeFlags = removeFlagsCollection{}
+
+var removeCommand = &command.Command{
+	Cmd: &cobra.Command{
+		Use:     "remove <name>",
+		Short:   "Remove a dependency and the dependencies only it imports.",
+		Example: "flow dependencies remove FlowToken",
+		Args:    cobra.ExactArgs(1),
+	},
+	Flags: &removeFlags,
+	RunS:  remove,
+}
+
+func remove(
+	args []string,
+	globalFlags command.GlobalFlags,
+	logger output.Logger,
+	flow flowkit.Services,
+	state *flowkit.State,
+) (command.Result, error) {
+	logger.Info(fmt.Sprintf("🔄 Removing dependency %s...", args[0]))
+
+	installer, err := NewDependencyInstaller(logger, state, flow)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error: %v", err))
+		return nil, err
+	}
+	installer.ConfigPaths = globalFlags.ConfigPaths
+	installer.Force = removeFlags.Force
+
+	removed, err := installer.Remove(args[0])
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error: %v", err))
+		return nil, err
+	}
+
+	logger.Info(fmt.Sprintf("✅  Removed %s. Check your flow.json", strings.Join(removed, ", ")))
+
+	return nil, nil
+}
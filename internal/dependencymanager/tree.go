@@ -0,0 +1,180 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dependencymanager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/output"
+
+	"github.com/onflow/flow-cli/internal/command"
+)
+
+type treeFlagsCollection struct {
+	Dot     bool `default:"false" flag:"dot" info:"Print the graph in Graphviz DOT format"`
+	Offline bool `default:"false" flag:"offline" info:"Resolve from the local dependency cache without accessing the network"`
+}
+
+var treeFlags = treeFlagsCollection{}
+
+var treeCommand = &command.Command{
+	Cmd: &cobra.Command{
+		Use:     "tree",
+		Short:   "Show the transitive import graph of all dependencies.",
+		Example: "flow dependencies tree\nflow dependencies tree --dot --save dependencies.dot",
+		Args:    cobra.NoArgs,
+	},
+	Flags: &treeFlags,
+	RunS:  tree,
+}
+
+func tree(
+	_ []string,
+	globalFlags command.GlobalFlags,
+	logger output.Logger,
+	flow flowkit.Services,
+	state *flowkit.State,
+) (command.Result, error) {
+	logger.StartProgress("Resolving dependencies...")
+	defer logger.StopProgress()
+
+	installer, err := NewDependencyInstaller(logger, state, flow)
+	if err != nil {
+		return nil, err
+	}
+	installer.ConfigPaths = globalFlags.ConfigPaths
+	installer.Offline = treeFlags.Offline
+
+	t, err := installer.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	return &treeResult{tree: t, dot: treeFlags.Dot}, nil
+}
+
+type treeResult struct {
+	tree *DependencyTree
+	dot  bool
+}
+
+var _ command.Result = &treeResult{}
+
+func (r *treeResult) JSON() any {
+	return r.tree
+}
+
+func (r *treeResult) String() string {
+	if r.dot {
+		return r.graphviz()
+	}
+
+	if len(r.tree.Roots) == 0 {
+		return "No dependencies found."
+	}
+
+	var b strings.Builder
+	expanded := make(map[string]bool)
+
+	var write func(id, prefix, branch string)
+	write = func(id, prefix, branch string) {
+		node := r.tree.Node(id)
+		b.WriteString(fmt.Sprintf("%s%s%s %s%s\n", prefix, branch, output.Bold(node.Contract), node.ID, nodeMarkers(node)))
+
+		if expanded[id] {
+			return
+		}
+		expanded[id] = true
+
+		childPrefix := prefix
+		switch branch {
+		case "├── ":
+			childPrefix += "│   "
+		case "└── ":
+			childPrefix += "    "
+		}
+
+		imports := r.tree.Imports(id)
+		for i, imported := range imports {
+			if i == len(imports)-1 {
+				write(imported, childPrefix, "└── ")
+			} else {
+				write(imported, childPrefix, "├── ")
+			}
+		}
+	}
+
+	for _, root := range r.tree.Roots {
+		write(root, "", "")
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (r *treeResult) Oneliner() string {
+	return fmt.Sprintf("%d contracts, %d imports", len(r.tree.Nodes), len(r.tree.Edges))
+}
+
+// graphviz renders the tree in DOT format, conflicts are red and unresolved contracts dashed.
+func (r *treeResult) graphviz() string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+
+	for _, node := range r.tree.Nodes {
+		attributes := []string{fmt.Sprintf("label=%q", fmt.Sprintf("%s\n%s", node.Contract, node.ID))}
+		if node.Conflict {
+			attributes = append(attributes, "color=red")
+		}
+		if node.Error != "" {
+			attributes = append(attributes, "style=dashed")
+		}
+		b.WriteString(fmt.Sprintf("\t%q [%s];\n", node.ID, strings.Join(attributes, ", ")))
+	}
+
+	for _, edge := range r.tree.Edges {
+		b.WriteString(fmt.Sprintf("\t%q -> %q;\n", edge.From, edge.To))
+	}
+
+	b.WriteString("}")
+
+	return b.String()
+}
+
+func nodeMarkers(node *TreeNode) string {
+	markers := make([]string, 0)
+	if node.Duplicate {
+		markers = append(markers, "duplicate")
+	}
+	if node.Conflict {
+		markers = append(markers, "conflict")
+	}
+	if node.Error != "" {
+		markers = append(markers, fmt.Sprintf("error: %s", node.Error))
+	}
+
+	if len(markers) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%s)", strings.Join(markers, ", "))
+}
@@ -0,0 +1,71 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dependencymanager
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/output"
+
+	"github.com/onflow/flow-cli/internal/command"
+)
+
+type updateFlagsCollection struct{}
+
+var updateFlags = updateFlagsCollection{}
+
+var updateCommand = &command.Command{
+	Cmd: &cobra.Command{
+		Use:     "update [names...]",
+		Short:   "Update dependencies to their latest on-chain version without prompting.",
+		Example: "flow dependencies update FlowToken NonFungibleToken",
+		Args:    cobra.ArbitraryArgs,
+	},
+	Flags: &updateFlags,
+	RunS:  update,
+}
+
+func update(
+	args []string,
+	globalFlags command.GlobalFlags,
+	logger output.Logger,
+	flow flowkit.Services,
+	state *flowkit.State,
+) (command.Result, error) {
+	logger.Info("🔄 Updating dependencies...")
+
+	installer, err := NewDependencyInstaller(logger, state, flow)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error: %v", err))
+		return nil, err
+	}
+	installer.ConfigPaths = globalFlags.ConfigPaths
+
+	if err := installer.Update(args); err != nil {
+		logger.Error(fmt.Sprintf("Error: %v", err))
+		return nil, err
+	}
+
+	logger.Info("✅  Dependencies updated. Check your flow.json")
+
+	return nil, nil
+}
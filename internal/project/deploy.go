@@ -23,7 +23,6 @@ import (
 	"errors"
 	"fmt"
 
-	flowsdk "github.com/onflow/flow-go-sdk"
 	"github.com/spf13/cobra"
 	"golang.org/x/exp/slices"
 
@@ -119,78 +118,20 @@ func (r *deployResult) Oneliner() string {
 // are referencing standard contract and if so warn the use that they should use the already
 // deployed contracts as an alias on mainnet instead of deploying their own copy.
 func checkForStandardContractUsageOnMainnet(state *flowkit.State, logger output.Logger, replace bool) error {
-	mainnetContracts := map[string]standardContract{
-		"FungibleToken": {
-			name:     "FungibleToken",
-			address:  flowsdk.HexToAddress("0xf233dcee88fe0abe"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/fungible-token",
-		},
-		"FlowToken": {
-			name:     "FlowToken",
-			address:  flowsdk.HexToAddress("0x1654653399040a61"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/flow-token",
-		},
-		"FlowFees": {
-			name:     "FlowFees",
-			address:  flowsdk.HexToAddress("0xf919ee77447b7497"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/flow-fees",
-		},
-		"FlowServiceAccount": {
-			name:     "FlowServiceAccount",
-			address:  flowsdk.HexToAddress("0xe467b9dd11fa00df"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/service-account",
-		},
-		"FlowStorageFees": {
-			name:     "FlowStorageFees",
-			address:  flowsdk.HexToAddress("0xe467b9dd11fa00df"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/service-account",
-		},
-		"FlowIDTableStaking": {
-			name:     "FlowIDTableStaking",
-			address:  flowsdk.HexToAddress("0x8624b52f9ddcd04a"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/staking-contract-reference",
-		},
-		"FlowEpoch": {
-			name:     "FlowEpoch",
-			address:  flowsdk.HexToAddress("0x8624b52f9ddcd04a"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/epoch-contract-reference",
-		},
-		"FlowClusterQC": {
-			name:     "FlowClusterQC",
-			address:  flowsdk.HexToAddress("0x8624b52f9ddcd04a"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/epoch-contract-reference",
-		},
-		"FlowDKG": {
-			name:     "FlowDKG",
-			address:  flowsdk.HexToAddress("0x8624b52f9ddcd04a"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/epoch-contract-reference",
-		},
-		"NonFungibleToken": {
-			name:     "NonFungibleToken",
-			address:  flowsdk.HexToAddress("0x1d7e57aa55817448"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/non-fungible-token",
-		},
-		"MetadataViews": {
-			name:     "MetadataViews",
-			address:  flowsdk.HexToAddress("0x1d7e57aa55817448"),
-			infoLink: "https://developers.flow.com/flow/core-contracts/nft-metadata",
-		},
-	}
-
 	contracts, err := state.DeploymentContractsByNetwork(config.MainnetNetwork)
 	if err != nil {
 		return err
 	}
 
 	for _, contract := range contracts {
-		standardContract, ok := mainnetContracts[contract.Name]
+		standardContract, ok := util.CoreContracts[contract.Name]
 		if !ok {
 			continue
 		}
 
 		logger.Info(fmt.Sprintf("It seems like you are trying to deploy %s to Mainnet \n", contract.Name))
-		logger.Info(fmt.Sprintf("It is a standard contract already deployed at address 0x%s \n", standardContract.address.String()))
-		logger.Info(fmt.Sprintf("You can read more about it here: %s \n", standardContract.infoLink))
+		logger.Info(fmt.Sprintf("It is a standard contract already deployed at address 0x%s \n", standardContract.Addresses[config.MainnetNetwork.Name].String()))
+		logger.Info(fmt.Sprintf("You can read more about it here: %s \n", standardContract.InfoLink))
 
 		if replace || util.WantToUseMainnetVersionPrompt() {
 			err := replaceContractWithAlias(state, standardContract)
@@ -202,22 +143,16 @@ func checkForStandardContractUsageOnMainnet(state *flowkit.State, logger output.
 	return nil
 }
 
-type standardContract struct {
-	name     string
-	address  flowsdk.Address
-	infoLink string
-}
-
-func replaceContractWithAlias(state *flowkit.State, standardContract standardContract) error {
-	contract, err := state.Config().Contracts.ByName(standardContract.name)
+func replaceContractWithAlias(state *flowkit.State, standardContract util.CoreContract) error {
+	contract, err := state.Config().Contracts.ByName(standardContract.Name)
 	if err != nil {
 		return err
 	}
-	contract.Aliases.Add(config.MainnetNetwork.Name, standardContract.address) // replace contract with an alias
+	contract.Aliases.Add(config.MainnetNetwork.Name, standardContract.Addresses[config.MainnetNetwork.Name]) // replace contract with an alias
 
 	for di, d := range state.Config().Deployments.ByNetwork(config.MainnetNetwork.Name) {
 		for ci, c := range d.Contracts {
-			if c.Name == standardContract.name {
+			if c.Name == standardContract.Name {
 				state.Config().Deployments[di].Contracts = slices.Delete(state.Config().Deployments[di].Contracts, ci, ci+1)
 				if len(state.Config().Deployments[di].Contracts) == 0 {
 					_ = state.Config().Deployments.Remove(d.Account, d.Network)
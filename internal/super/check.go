@@ -0,0 +1,237 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package super
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/parser"
+	"github.com/onflow/cadence/runtime/sema"
+	"github.com/onflow/cadence/runtime/stdlib"
+	"github.com/onflow/flow-go-sdk"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/config"
+	flowkitProject "github.com/onflow/flowkit/project"
+)
+
+const (
+	scriptKind      = "script"
+	transactionKind = "transaction"
+)
+
+// codeCheck is the result of checking a script or transaction in the project.
+type codeCheck struct {
+	path string
+	kind string
+	// value is the result of the script execution, it is nil if the script was not executed.
+	value cadence.Value
+	// needsArguments is set for scripts that were only checked, because they require arguments to be executed.
+	needsArguments bool
+	err            error
+}
+
+// checkCode type-checks the script or transaction on the provided path against the contracts deployed
+// on the emulator, scripts that don't require any arguments are also executed.
+func (p *project) checkCode(path string, kind string) *codeCheck {
+	check := &codeCheck{
+		path: path,
+		kind: kind,
+	}
+
+	code, err := p.state.ReadFile(path)
+	if err != nil {
+		check.err = err
+		return check
+	}
+
+	program, err := flowkitProject.NewProgram(code, nil, path)
+	if err != nil {
+		check.err = err
+		return check
+	}
+
+	if program.HasImports() {
+		contracts, err := p.state.DeploymentContractsByNetwork(config.EmulatorNetwork)
+		if err != nil {
+			check.err = err
+			return check
+		}
+
+		importReplacer := flowkitProject.NewImportReplacer(contracts, p.state.AliasesForNetwork(config.EmulatorNetwork))
+		program, err = importReplacer.Replace(program)
+		if err != nil {
+			check.err = err
+			return check
+		}
+	}
+
+	checker := newCodeChecker(p.flow)
+	parsed, err := checker.check(program.Code(), common.StringLocation(path), kind == scriptKind)
+	if err != nil {
+		check.err = err
+		return check
+	}
+
+	if kind != scriptKind {
+		return check
+	}
+
+	if hasParameters(parsed) {
+		check.needsArguments = true
+		return check
+	}
+
+	check.value, check.err = p.flow.ExecuteScript(
+		context.Background(),
+		flowkit.Script{Code: code, Location: path},
+		flowkit.LatestScriptQuery,
+	)
+	return check
+}
+
+// hasParameters returns whether the main function of the script has any parameters.
+func hasParameters(program *ast.Program) bool {
+	for _, function := range program.FunctionDeclarations() {
+		if function.Identifier.Identifier == "main" {
+			return function.ParameterList != nil && len(function.ParameterList.Parameters) > 0
+		}
+	}
+
+	return false
+}
+
+// codeChecker type-checks Cadence code and resolves the imported contracts from the accounts on the network.
+type codeChecker struct {
+	flow    flowkit.Services
+	imports map[common.Location]*sema.Elaboration
+	codes   map[common.Location][]byte
+}
+
+func newCodeChecker(flow flowkit.Services) *codeChecker {
+	return &codeChecker{
+		flow:    flow,
+		imports: make(map[common.Location]*sema.Elaboration),
+		codes:   make(map[common.Location][]byte),
+	}
+}
+
+// check parses and type-checks the code, the script standard library is only available if script is set.
+func (c *codeChecker) check(code []byte, location common.Location, script bool) (*ast.Program, error) {
+	c.codes[location] = code
+
+	program, err := parser.ParseProgram(nil, code, parser.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	env := runtime.NewBaseInterpreterEnvironment(runtime.Config{
+		AccountLinkingEnabled:        true,
+		AttachmentsEnabled:           true,
+		CapabilityControllersEnabled: true,
+	})
+	if script {
+		env.DeclareValue(stdlib.NewGetAuthAccountFunction(env), nil)
+	}
+
+	conf := *env.CheckerConfig
+	conf.CheckHandler = nil // the default handler reports metrics to the runtime interface which we don't have
+	conf.LocationHandler = resolveLocation
+	conf.ImportHandler = c.resolveImport
+
+	checker, err := sema.NewChecker(program, location, nil, &conf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checker.Check()
+	if err != nil {
+		var checkerErr *sema.CheckerError
+		if errors.As(err, &checkerErr) {
+			checkerErr.Codes = c.codes // include the code in the error output
+		}
+		return nil, err
+	}
+
+	c.imports[location] = checker.Elaboration
+	return program, nil
+}
+
+// resolveImport checks the imported contract code deployed on the network.
+func (c *codeChecker) resolveImport(_ *sema.Checker, location common.Location, _ ast.Range) (sema.Import, error) {
+	if location == stdlib.CryptoCheckerLocation {
+		return sema.ElaborationImport{Elaboration: stdlib.CryptoChecker().Elaboration}, nil
+	}
+
+	if elaboration, ok := c.imports[location]; ok {
+		return sema.ElaborationImport{Elaboration: elaboration}, nil
+	}
+
+	addressLocation, ok := location.(common.AddressLocation)
+	if !ok {
+		return nil, fmt.Errorf("import of %s could not be resolved", location)
+	}
+
+	account, err := c.flow.GetAccount(context.Background(), flow.Address(addressLocation.Address))
+	if err != nil {
+		return nil, err
+	}
+
+	code, ok := account.Contracts[addressLocation.Name]
+	if !ok {
+		return nil, fmt.Errorf("contract %s is not deployed to account 0x%s", addressLocation.Name, addressLocation.Address)
+	}
+
+	_, err = c.check(code, addressLocation, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return sema.ElaborationImport{Elaboration: c.imports[location]}, nil
+}
+
+// resolveLocation resolves each identifier imported from an address to the contract location.
+func resolveLocation(identifiers []ast.Identifier, location common.Location) ([]sema.ResolvedLocation, error) {
+	addressLocation, ok := location.(common.AddressLocation)
+	if !ok || len(identifiers) == 0 {
+		return []sema.ResolvedLocation{{
+			Location:    location,
+			Identifiers: identifiers,
+		}}, nil
+	}
+
+	resolved := make([]sema.ResolvedLocation, len(identifiers))
+	for i, identifier := range identifiers {
+		resolved[i] = sema.ResolvedLocation{
+			Location: common.AddressLocation{
+				Address: addressLocation.Address,
+				Name:    identifier.Identifier,
+			},
+			Identifiers: []ast.Identifier{identifier},
+		}
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,94 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package super
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CheckCode(t *testing.T) {
+	t.Run("Valid transaction", func(t *testing.T) {
+		checker := newCodeChecker(nil)
+		code := []byte(`
+			transaction(amount: UFix64) {
+				prepare(signer: AuthAccount) {
+					log(signer.address)
+				}
+			}`)
+
+		_, err := checker.check(code, common.StringLocation("tx.cdc"), false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Invalid transaction", func(t *testing.T) {
+		checker := newCodeChecker(nil)
+		code := []byte(`
+			transaction {
+				prepare(signer: AuthAccount) {
+					let amount: Int = "foo"
+				}
+			}`)
+
+		_, err := checker.check(code, common.StringLocation("tx.cdc"), false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "mismatched types")
+		assert.Contains(t, err.Error(), `let amount: Int = "foo"`)
+	})
+
+	t.Run("Script standard library", func(t *testing.T) {
+		code := []byte(`
+			pub fun main(): Address {
+				return getAuthAccount(0x01).address
+			}`)
+
+		_, err := newCodeChecker(nil).check(code, common.StringLocation("script.cdc"), true)
+		assert.NoError(t, err)
+
+		_, err = newCodeChecker(nil).check(code, common.StringLocation("tx.cdc"), false)
+		assert.Error(t, err)
+	})
+
+	t.Run("Crypto import", func(t *testing.T) {
+		code := []byte(`
+			import Crypto
+
+			pub fun main(): Bool {
+				return Crypto.KeyList().get(keyIndex: 0) == nil
+			}`)
+
+		_, err := newCodeChecker(nil).check(code, common.StringLocation("script.cdc"), true)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Script parameters", func(t *testing.T) {
+		checker := newCodeChecker(nil)
+
+		program, err := checker.check([]byte(`pub fun main(a: Int): Int { return a }`), common.StringLocation("a.cdc"), true)
+		require.NoError(t, err)
+		assert.True(t, hasParameters(program))
+
+		program, err = checker.check([]byte(`pub fun main(): Int { return 1 }`), common.StringLocation("b.cdc"), true)
+		require.NoError(t, err)
+		assert.False(t, hasParameters(program))
+	})
+}
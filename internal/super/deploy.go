@@ -0,0 +1,431 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package super
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/parser"
+	"github.com/onflow/flow-go-sdk"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/config"
+	flowkitProject "github.com/onflow/flowkit/project"
+
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+// incompatibleUpdate matches the errors of contract updates that are not compatible with the deployed contract.
+// See: https://developers.flow.com/cadence/language/contract-updatability
+var incompatibleUpdate = regexp.MustCompile(
+	"cannot update contract|mismatching field|incompatible type annotations|found new field|trying to convert|" +
+		"conformances does not match|mismatching enum case|missing cases in enum|missing [\\w ]+ declaration",
+)
+
+// snapshotter creates and loads a snapshot of the emulator state.
+type snapshotter interface {
+	// createSnapshot of the current state, which replaces the previous snapshot.
+	createSnapshot() error
+	// loadSnapshot reverts to the state of the last snapshot.
+	loadSnapshot() error
+}
+
+// contractUpdate is the result of updating a contract in place.
+type contractUpdate struct {
+	duration time.Duration
+	err      error
+}
+
+// deployedContract is a project contract together with the time it took to deploy it.
+type deployedContract struct {
+	*flowkitProject.Contract
+	// duration of the deployment, it is zero if the contract was already deployed and left unchanged.
+	duration time.Duration
+	// updated is set if the contract was updated in place instead of deployed again.
+	updated bool
+}
+
+// deploymentError contains the errors of the contracts that failed to deploy by the contract name.
+type deploymentError struct {
+	contracts map[string]error
+}
+
+func (d *deploymentError) Contracts() map[string]error {
+	return d.contracts
+}
+
+func (d *deploymentError) Error() string {
+	names := maps.Keys(d.contracts)
+	slices.Sort(names)
+
+	errs := make([]string, len(names))
+	for i, name := range names {
+		errs[i] = fmt.Sprintf("%s: %s", name, d.contracts[name].Error())
+	}
+	return strings.Join(errs, ", ")
+}
+
+// contractGraph of the project contracts deployed to the emulator.
+type contractGraph struct {
+	// sorted contracts in the deployment order, each contract comes after all the contracts it imports.
+	sorted []*flowkitProject.Contract
+	// imports of each contract by the contract name.
+	imports map[string][]string
+}
+
+// newContractGraph builds the graph of the contracts in the emulator deployments from the state.
+func newContractGraph(state *flowkit.State) (*contractGraph, error) {
+	contracts, err := state.DeploymentContractsByNetwork(config.EmulatorNetwork)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := flowkitProject.NewDeployment(contracts, state.AliasesForNetwork(config.EmulatorNetwork))
+	if err != nil {
+		return nil, err
+	}
+
+	sorted, err := deployment.Sort()
+	if err != nil {
+		return nil, err
+	}
+
+	byLocation := make(map[string]string)
+	byName := make(map[string]bool)
+	for _, contract := range sorted {
+		byLocation[filepath.Clean(contract.Location())] = contract.Name
+		byName[contract.Name] = true
+	}
+
+	graph := &contractGraph{
+		sorted:  sorted,
+		imports: make(map[string][]string),
+	}
+
+	for _, contract := range sorted {
+		program, err := parser.ParseProgram(nil, contract.Code(), parser.Config{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, location := range importLocations(program) {
+			// find contract by the path import, otherwise by the identifier import
+			if name, ok := byLocation[filepath.Join(filepath.Dir(contract.Location()), location)]; ok {
+				graph.imports[contract.Name] = append(graph.imports[contract.Name], name)
+			} else if byName[location] {
+				graph.imports[contract.Name] = append(graph.imports[contract.Name], location)
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// importLocations returns the string locations of the program imports, imports from addresses are not included.
+func importLocations(program *ast.Program) []string {
+	locations := make([]string, 0)
+	for _, declaration := range program.ImportDeclarations() {
+		if location, ok := declaration.Location.(common.StringLocation); ok {
+			locations = append(locations, location.String())
+		}
+	}
+
+	return locations
+}
+
+// dependents returns the provided contract together with all the contracts that import it directly or
+// transitively, in the deployment order.
+func (g *contractGraph) dependents(name string) []*flowkitProject.Contract {
+	affected := map[string]bool{name: true}
+	dependents := make([]*flowkitProject.Contract, 0)
+
+	for _, contract := range g.sorted { // imported contracts always come first in the deployment order
+		if !affected[contract.Name] {
+			for _, imported := range g.imports[contract.Name] {
+				if affected[imported] {
+					affected[contract.Name] = true
+					break
+				}
+			}
+		}
+
+		if affected[contract.Name] {
+			dependents = append(dependents, contract)
+		}
+	}
+
+	return dependents
+}
+
+// undeploy removes the contract on the provided path from the emulator, and before that, all the contracts
+// that import it, so none of the deployed contracts are left with an import of a removed contract.
+//
+// Removed contracts are deployed again with the next deploy.
+func (p *project) undeploy(path string) error {
+	name, err := p.contractName(path)
+	if err != nil {
+		return err
+	}
+
+	// use the graph of the last deployment since it matches the contracts on the emulator
+	if p.graph == nil { // the project failed to deploy, so at least remove the contract
+		return p.removeDeployed(name)
+	}
+
+	dependents := p.graph.dependents(name)
+	for i := len(dependents) - 1; i >= 0; i-- {
+		err := p.removeDeployed(dependents[i].Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeDeployed removes the contract from the account it is deployed to on the emulator.
+func (p *project) removeDeployed(name string) error {
+	for _, deployment := range *p.state.Deployments() {
+		deploysContract := slices.ContainsFunc(deployment.Contracts, func(c config.ContractDeployment) bool {
+			return c.Name == name
+		})
+		if deployment.Network != emulator || !deploysContract {
+			continue
+		}
+
+		acc, err := p.state.Accounts().ByName(deployment.Account)
+		if err != nil {
+			return err
+		}
+
+		flowAcc, err := p.flow.GetAccount(context.Background(), acc.Address)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := flowAcc.Contracts[name]; !ok {
+			return nil // contract failed to deploy before, eg. because of missing initialization arguments
+		}
+
+		_, err = p.flow.RemoveContract(context.Background(), acc, name)
+		return err
+	}
+
+	return nil
+}
+
+// deploy all the project contracts that are not yet deployed on the emulator, in the deployment order.
+//
+// Contracts that are already deployed are left unchanged, so after a contract change only the contracts
+// that were removed by undeploy are deployed again.
+func (p *project) deploy() {
+	p.deployed, p.deployErr = nil, nil
+
+	deployErr := &deploymentError{contracts: make(map[string]error)}
+	for name, err := range p.contractErrs {
+		deployErr.contracts[name] = fmt.Errorf("failed to deploy contract %s: %w", name, err)
+	}
+
+	graph, err := newContractGraph(p.state)
+	if err != nil {
+		p.graph, p.deployErr = nil, err
+		if len(deployErr.contracts) > 0 { // imports of the contracts missing from the deployment can't be resolved
+			p.deployErr = deployErr
+		}
+		return
+	}
+	p.graph = graph
+
+	deployedContracts := make(map[flow.Address]map[string][]byte)
+
+	for _, contract := range graph.sorted {
+		existing, ok := deployedContracts[contract.AccountAddress]
+		if !ok {
+			flowAcc, err := p.flow.GetAccount(context.Background(), contract.AccountAddress)
+			if err != nil {
+				p.deployErr = err
+				return
+			}
+			existing = flowAcc.Contracts
+			deployedContracts[contract.AccountAddress] = existing
+		}
+
+		deployed := &deployedContract{Contract: contract}
+		if update, ok := p.updates[contract.Name]; ok {
+			if update.err != nil {
+				deployErr.contracts[contract.Name] = fmt.Errorf("failed to update contract %s: %w", contract.Name, update.err)
+				continue
+			}
+			deployed.duration, deployed.updated = update.duration, true
+		} else if _, ok := existing[contract.Name]; !ok {
+			err := p.deployContract(deployed)
+			if err != nil {
+				deployErr.contracts[contract.Name] = fmt.Errorf("failed to deploy contract %s: %w", contract.Name, err)
+				continue
+			}
+		}
+
+		p.deployed = append(p.deployed, deployed)
+	}
+
+	p.updates = make(map[string]*contractUpdate)
+	if len(deployErr.contracts) > 0 {
+		p.deployErr = deployErr
+	}
+}
+
+// deployContract to the account and measure the time the deployment took.
+func (p *project) deployContract(contract *deployedContract) error {
+	acc, err := p.state.Accounts().ByName(contract.AccountName)
+	if err != nil {
+		return fmt.Errorf("target account for deploying contract not found in configuration")
+	}
+
+	start := time.Now()
+	_, _, err = p.flow.AddContract(
+		context.Background(),
+		acc,
+		flowkit.Script{Code: contract.Code(), Args: contract.Args, Location: contract.Location()},
+		flowkit.UpdateExistingContract(false),
+	)
+	if err != nil {
+		return err
+	}
+
+	contract.duration = time.Since(start)
+	return nil
+}
+
+// updateContract in place, which keeps the data stored in the accounts.
+//
+// If the change can't be applied as an update, because it is incompatible or the initialization arguments changed,
+// the contract is deployed again, see redeployContract. It returns whether the project was deployed.
+func (p *project) updateContract(change contractChange) (bool, error) {
+	name, err := p.contractName(change.path)
+	if err != nil {
+		return false, err
+	}
+
+	p.removeContract(change.path, change.account)
+	p.addChangedContract(change.path, change.account)
+
+	contracts, err := p.state.DeploymentContractsByNetwork(config.EmulatorNetwork)
+	if err != nil {
+		p.updates[name] = &contractUpdate{err: err}
+		return false, nil
+	}
+
+	var contract *flowkitProject.Contract
+	for _, c := range contracts {
+		if c.Name == name {
+			contract = c
+		}
+	}
+	if contract == nil {
+		return false, nil // contract is aliased on the emulator, so it's not deployed
+	}
+
+	if change.args { // initialization arguments are only used when the contract is deployed
+		return p.redeployContract(change.path, name, "its initialization arguments changed", nil)
+	}
+
+	acc, err := p.state.Accounts().ByName(contract.AccountName)
+	if err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	_, _, updateErr := p.flow.AddContract(
+		context.Background(),
+		acc,
+		flowkit.Script{Code: contract.Code(), Args: contract.Args, Location: contract.Location()},
+		flowkit.UpdateExistingContract(true),
+	)
+	if updateErr == nil {
+		p.updates[name] = &contractUpdate{duration: time.Since(start)}
+		return false, nil
+	}
+
+	if strings.Contains(updateErr.Error(), "is the same as the contract provided for update") {
+		p.updates[name] = &contractUpdate{} // no changes, so nothing was updated
+		return false, nil
+	}
+
+	if !incompatibleUpdate.MatchString(updateErr.Error()) {
+		p.updates[name] = &contractUpdate{err: updateErr}
+		return false, nil
+	}
+
+	return p.redeployContract(change.path, name, "the changes are incompatible with an update", updateErr)
+}
+
+// redeployContract removes the contract and the contracts importing it and deploys them again, which removes
+// their stored data. A snapshot of the emulator state is created before, so once the project is deployed the
+// developer can choose to roll back to the state before the change.
+func (p *project) redeployContract(path string, name string, reason string, cause error) (bool, error) {
+	err := p.snapshots.createSnapshot()
+	if err != nil {
+		return false, fmt.Errorf("failed to create a snapshot of the emulator state: %w", err)
+	}
+
+	err = p.undeploy(path)
+	if err != nil {
+		return false, err
+	}
+
+	p.deploy()
+	p.render()
+	if !p.rollbackPrompt(name, reason) {
+		return true, nil
+	}
+
+	err = p.snapshots.loadSnapshot()
+	if err != nil {
+		return false, fmt.Errorf("failed to roll back to the emulator state snapshot: %w", err)
+	}
+
+	rollbackErr := fmt.Errorf("contract %s can't be updated because %s", name, reason)
+	if cause != nil {
+		rollbackErr = cause
+	}
+	p.updates[name] = &contractUpdate{
+		err: fmt.Errorf("%w\n\nThe emulator state was rolled back to the state before the change", rollbackErr),
+	}
+	p.deploy()
+
+	return true, nil
+}
+
+// rollbackPrompt asks whether to roll back to the emulator state before the contract was deployed again.
+func rollbackPrompt(name string, reason string) bool {
+	return util.GenericBoolPrompt(fmt.Sprintf(
+		"Contract %s was deployed again because %s, which removed the data stored by it and the contracts importing it. Roll back to the emulator state before the change?",
+		name,
+		reason,
+	))
+}
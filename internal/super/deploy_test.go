@@ -0,0 +1,238 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package super
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/accounts"
+	"github.com/onflow/flowkit/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+func Test_ContractGraph(t *testing.T) {
+	_, state, rw := util.TestMocks(t)
+
+	contracts := map[string]string{
+		"A": `pub contract A {}`,
+		"B": `import "A" pub contract B {}`,
+		"C": `import B from "./B.cdc" pub contract C {}`,
+		"D": `pub contract D {}`,
+		"E": `import "D" import "C" pub contract E {}`,
+	}
+
+	deployment := config.Deployment{Network: emulator, Account: defaultAccount}
+	for _, name := range []string{"E", "D", "C", "B", "A"} {
+		path := "cadence/contracts/" + name + ".cdc"
+		require.NoError(t, rw.WriteFile(path, []byte(contracts[name]), 0644))
+		state.Contracts().AddOrUpdate(config.Contract{Name: name, Location: path})
+		deployment.AddContract(config.ContractDeployment{Name: name})
+	}
+	state.Deployments().AddOrUpdate(deployment)
+	state.Accounts().AddOrUpdate(&accounts.Account{
+		Name:    defaultAccount,
+		Address: flow.HexToAddress("0x01"),
+	})
+
+	graph, err := newContractGraph(state)
+	require.NoError(t, err)
+
+	names := func(name string) []string {
+		dependents := make([]string, 0)
+		for _, contract := range graph.dependents(name) {
+			dependents = append(dependents, contract.Name)
+		}
+		return dependents
+	}
+
+	assert.Equal(t, []string{"A", "B", "C", "E"}, names("A"))
+	assert.Equal(t, []string{"C", "E"}, names("C"))
+	assert.Equal(t, []string{"D", "E"}, names("D"))
+	assert.Equal(t, []string{"E"}, names("E"))
+}
+
+func Test_IncompatibleUpdate(t *testing.T) {
+	incompatible := []string{
+		"error: found new field `m` in `A`",
+		"error: mismatching field `n` in `A`",
+		"error: missing resource declaration `R`",
+		"error: conformances does not match in `R`",
+	}
+	for _, err := range incompatible {
+		assert.True(t, incompatibleUpdate.MatchString(err), err)
+	}
+
+	compatible := []string{
+		"error: mismatched types",
+		"error: cannot find variable in this scope: `foo`",
+	}
+	for _, err := range compatible {
+		assert.False(t, incompatibleUpdate.MatchString(err), err)
+	}
+}
+
+type testSnapshots struct {
+	created int
+	loaded  int
+}
+
+func (s *testSnapshots) createSnapshot() error {
+	s.created++
+	return nil
+}
+
+func (s *testSnapshots) loadSnapshot() error {
+	s.loaded++
+	return nil
+}
+
+func Test_UpdateContract(t *testing.T) {
+	setup := func(t *testing.T, updateErr error, rollback bool) (*project, *testSnapshots, map[string][]byte) {
+		srv, state, rw := util.TestMocks(t)
+
+		address := flow.HexToAddress("0x01")
+		state.Accounts().AddOrUpdate(&accounts.Account{Name: defaultAccount, Address: address})
+		state.Deployments().AddOrUpdate(config.Deployment{Network: emulator, Account: defaultAccount})
+
+		deployed := make(map[string][]byte)
+		srv.GetAccount.Run(func(args mock.Arguments) {
+			srv.GetAccount.Return(&flow.Account{Address: address, Contracts: deployed}, nil)
+		})
+		srv.AddContract.Run(func(args mock.Arguments) {
+			script := args.Get(2).(flowkit.Script)
+			name := map[string]string{"cadence/contracts/A.cdc": "A", "cadence/contracts/B.cdc": "B"}[script.Location]
+			if _, ok := deployed[name]; ok {
+				srv.AddContract.Return(flow.EmptyID, false, updateErr)
+				return
+			}
+			deployed[name] = script.Code
+			srv.AddContract.Return(flow.EmptyID, false, nil)
+		})
+		srv.RemoveContract.Run(func(args mock.Arguments) {
+			delete(deployed, args.Get(2).(string))
+		})
+
+		require.NoError(t, rw.WriteFile("cadence/contracts/A.cdc", []byte(`pub contract A {}`), 0644))
+		require.NoError(t, rw.WriteFile("cadence/contracts/B.cdc", []byte(`import "A" pub contract B {}`), 0644))
+
+		snapshots := &testSnapshots{}
+		p := &project{
+			flow:           srv.Mock,
+			state:          state,
+			pathNameLookup: make(map[string]string),
+			checks:         make(map[string]*codeCheck),
+			deploymentArgs: make(map[string][]cadence.Value),
+			snapshots:      snapshots,
+			updates:        make(map[string]*contractUpdate),
+			rollbackPrompt: func(string, string) bool { return rollback },
+		}
+		require.NoError(t, p.addContract("cadence/contracts/A.cdc", defaultAccount))
+		require.NoError(t, p.addContract("cadence/contracts/B.cdc", defaultAccount))
+		p.deploy()
+		require.NoError(t, p.deployErr)
+
+		return p, snapshots, deployed
+	}
+
+	change := contractChange{status: changed, path: "cadence/contracts/A.cdc", account: defaultAccount}
+
+	t.Run("Compatible", func(t *testing.T) {
+		p, snapshots, deployed := setup(t, nil, true)
+
+		redeployed, err := p.updateContract(change)
+		require.NoError(t, err)
+		assert.False(t, redeployed)
+		assert.Equal(t, 0, snapshots.created)
+
+		p.deploy()
+		require.NoError(t, p.deployErr)
+		assert.Len(t, deployed, 2)
+	})
+
+	t.Run("Incompatible", func(t *testing.T) {
+		p, snapshots, deployed := setup(t, fmt.Errorf("error: found new field `n` in `A`"), false)
+		deployed["B"] = []byte("removed by the redeployment")
+
+		redeployed, err := p.updateContract(change)
+		require.NoError(t, err)
+		assert.True(t, redeployed)
+		assert.Equal(t, 1, snapshots.created)
+		assert.Equal(t, 0, snapshots.loaded)
+		require.NoError(t, p.deployErr)
+		assert.Equal(t, []byte(`import "A" pub contract B {}`), deployed["B"])
+	})
+
+	t.Run("Incompatible rolled back", func(t *testing.T) {
+		p, snapshots, _ := setup(t, fmt.Errorf("error: found new field `n` in `A`"), true)
+
+		redeployed, err := p.updateContract(change)
+		require.NoError(t, err)
+		assert.True(t, redeployed)
+		assert.Equal(t, 1, snapshots.created)
+		assert.Equal(t, 1, snapshots.loaded)
+		assert.ErrorContains(t, p.deployErr, "found new field `n` in `A`")
+		assert.ErrorContains(t, p.deployErr, "The emulator state was rolled back to the state before the change")
+	})
+
+	t.Run("Changed arguments", func(t *testing.T) {
+		p, snapshots, _ := setup(t, nil, true)
+
+		redeployed, err := p.updateContract(contractChange{
+			status:  changed,
+			path:    "cadence/contracts/A.cdc",
+			account: defaultAccount,
+			args:    true,
+		})
+		require.NoError(t, err)
+		assert.True(t, redeployed)
+		assert.Equal(t, 1, snapshots.created)
+		assert.Equal(t, 1, snapshots.loaded)
+		assert.ErrorContains(t, p.deployErr, "contract A can't be updated because its initialization arguments changed")
+	})
+
+	t.Run("Invalid arguments file", func(t *testing.T) {
+		p, _, _ := setup(t, nil, true)
+		rw := p.state.ReaderWriter()
+		require.NoError(t, rw.WriteFile("cadence/contracts/A.args.json", []byte(`[{"type": "Int"`), 0644))
+
+		argsChange := contractChange{status: changed, path: "cadence/contracts/A.cdc", account: defaultAccount, args: true}
+		redeployed, err := p.updateContract(argsChange)
+		require.NoError(t, err)
+		assert.False(t, redeployed)
+
+		p.deploy()
+		var deployErr *deploymentError
+		require.ErrorAs(t, p.deployErr, &deployErr)
+		assert.ErrorContains(t, deployErr.Contracts()["A"], "invalid initialization arguments in cadence/contracts/A.args.json")
+
+		require.NoError(t, rw.WriteFile("cadence/contracts/A.args.json", []byte(`[]`), 0644))
+		_, err = p.updateContract(argsChange)
+		require.NoError(t, err)
+		p.deploy()
+		assert.NoError(t, p.deployErr)
+	})
+}
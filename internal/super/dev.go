@@ -22,7 +22,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
 
 	"github.com/onflow/cadence/runtime/parser"
 	"github.com/spf13/cobra"
@@ -33,7 +34,9 @@ import (
 	"github.com/onflow/flow-cli/internal/command"
 )
 
-type flagsDev struct{}
+type flagsDev struct {
+	KeepState bool `default:"false" flag:"keep-state" info:"Keep the data stored in accounts by updating changed contracts in place, with a snapshot to roll back to"`
+}
 
 var devFlags = flagsDev{}
 
@@ -61,25 +64,32 @@ func dev(
 		return nil, err
 	}
 
-	err = flow.Ping()
+	service, err := state.EmulatorServiceAccount()
 	if err != nil {
-		logger.Error("Error connecting to emulator. Make sure you started an emulator using 'flow emulator' command.")
-		logger.Info(fmt.Sprintf("%s This tool requires emulator to function. Emulator needs to be run inside the project root folder where the configuration file ('flow.json') exists.\n\n", output.TryEmoji()))
-		return nil, nil
+		return nil, err
 	}
 
-	service, err := state.EmulatorServiceAccount()
+	logger.StartProgress("Starting the emulator...")
+	emu, err := startEmulator(service, flow, devFlags.KeepState)
+	logger.StopProgress()
 	if err != nil {
 		return nil, err
 	}
+	defer emu.stop()
 
 	flow.SetLogger(output.NewStdoutLogger(output.NoneLog))
 
+	var snapshots snapshotter
+	if devFlags.KeepState {
+		snapshots = emu
+	}
+
 	project, err := newProject(
 		*service,
 		flow,
 		state,
 		newProjectFiles(dir),
+		snapshots,
 	)
 	if err != nil {
 		fmt.Printf("%s Failed to run the command, please make sure you ran 'flow setup' command first and that you are running this command inside the project ROOT folder.\n\n", output.TryEmoji())
@@ -88,11 +98,6 @@ func dev(
 
 	err = project.startup()
 	if err != nil {
-		if strings.Contains(err.Error(), "does not have a valid signature") {
-			fmt.Printf("%s Failed to run the command, please make sure you started the emulator inside the project ROOT folder by running 'flow emulator'.\n\n", output.TryEmoji())
-			return nil, nil
-		}
-
 		var parseErr parser.Error
 		if errors.As(err, &parseErr) {
 			fmt.Println(err) // we just print the error but keep watching files for changes, since they might fix the issue
@@ -101,9 +106,22 @@ func dev(
 		}
 	}
 
-	err = project.watch()
-	if err != nil {
-		return nil, err
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- project.watch()
+	}()
+
+	select {
+	case <-interrupt:
+		fmt.Printf("\n%s Stopping the emulator\n", output.StopEmoji())
+	case err := <-watchErr:
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return nil, nil
@@ -0,0 +1,208 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package super
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-emulator/server"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/accounts"
+)
+
+// How long to wait for the emulator to accept connections after it started listening.
+const emulatorReadyTimeout = 10 * time.Second
+
+// Defaults of the flow emulator command, the storage defaults are taken from flow-go the same way the command does.
+const (
+	emulatorTokenSupply         = "1000000000.0"
+	emulatorTransactionGasLimit = 9999
+	emulatorScriptGasLimit      = 100000
+	emulatorTransactionExpiry   = 10
+)
+
+// Names of the snapshots used in turns, since a snapshot can't be replaced while the emulator state is loaded from it.
+var snapshotNames = [2]string{"flow-dev", "flow-dev-previous"}
+
+// devEmulator is an emulator running in the same process as flow dev.
+type devEmulator struct {
+	server  *server.EmulatorServer
+	stopped chan struct{}
+	// dir contains the emulator database and its snapshots, it is only set if snapshots are enabled.
+	dir string
+	// snapshot is the name of the last created snapshot, and loaded the name of the snapshot the state was loaded from.
+	snapshot string
+	loaded   string
+}
+
+// emulatorConfig returns the configuration of the emulator using the service account of the project,
+// contracts can be removed so they can be redeployed when they change in incompatible ways.
+//
+// If a directory is provided the emulator database is stored in it, which enables snapshots that are
+// stored as files in the same directory, so they can be removed when they are replaced.
+func emulatorConfig(service *accounts.Account, dir string) (*server.Config, error) {
+	privateKey, err := service.Key.PrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("only hexadecimal keys can be used as the emulator service account key")
+	}
+
+	err = service.Key.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key in %s emulator configuration, %w", service.Name, err)
+	}
+
+	tokenSupply, err := cadence.NewUFix64(emulatorTokenSupply)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &server.Config{
+		ServicePrivateKey:         *privateKey,
+		ServiceKeySigAlgo:         service.Key.SigAlgo(),
+		ServiceKeyHashAlgo:        service.Key.HashAlgo(),
+		TransactionMaxGasLimit:    emulatorTransactionGasLimit,
+		ScriptGasLimit:            emulatorScriptGasLimit,
+		TransactionExpiry:         emulatorTransactionExpiry,
+		GenesisTokenSupply:        tokenSupply,
+		StorageLimitEnabled:       true,
+		MinimumStorageReservation: fvm.DefaultMinimumStorageReservation,
+		StorageMBPerFLOW:          fvm.DefaultStorageMBPerFLOW,
+		ContractRemovalEnabled:    true,
+		WithContracts:             true,
+	}
+
+	if dir != "" {
+		conf.SqliteURL = dir
+		conf.Snapshot = true
+	}
+
+	return conf, nil
+}
+
+// startEmulator starts the emulator with the service account of the project and waits until it's ready,
+// snapshots of the emulator state can only be created if they are enabled.
+func startEmulator(service *accounts.Account, flow flowkit.Services, snapshots bool) (*devEmulator, error) {
+	dir := ""
+	if snapshots {
+		var err error
+		dir, err = os.MkdirTemp("", "flow-dev")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the emulator database directory: %w", err)
+		}
+	}
+
+	conf, err := emulatorConfig(service, dir)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	// emulator logs would interleave with the output of flow dev, errors surface through the gateway instead
+	logger := zerolog.Nop()
+
+	emulatorServer := server.NewEmulatorServer(&logger, conf)
+	if emulatorServer == nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to create the emulator")
+	}
+
+	err = emulatorServer.Listen()
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to start the emulator, make sure no other emulator is running: %w", err)
+	}
+
+	emu := &devEmulator{
+		server:  emulatorServer,
+		stopped: make(chan struct{}),
+		dir:     dir,
+	}
+	go func() {
+		emulatorServer.Start()
+		close(emu.stopped)
+	}()
+
+	deadline := time.Now().Add(emulatorReadyTimeout)
+	for {
+		err = flow.Ping()
+		if err == nil {
+			return emu, nil
+		}
+		if time.Now().After(deadline) {
+			emu.stop()
+			return nil, fmt.Errorf("emulator didn't start in %s: %w", emulatorReadyTimeout, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// stop shuts the emulator down, waits until all its servers stopped and removes its database.
+func (e *devEmulator) stop() {
+	e.server.Stop()
+	<-e.stopped
+	if e.dir != "" {
+		_ = os.RemoveAll(e.dir)
+	}
+}
+
+// createSnapshot of the current emulator state, which replaces the previously created snapshot.
+func (e *devEmulator) createSnapshot() error {
+	name := snapshotNames[0]
+	if e.loaded == name {
+		name = snapshotNames[1]
+	}
+
+	// the emulator stores a snapshot as a file in the database directory and fails if the file exists
+	err := os.Remove(filepath.Join(e.dir, fmt.Sprintf("snapshot_%s", name)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	err = e.server.Emulator().CreateSnapshot(name)
+	if err != nil {
+		return err
+	}
+
+	e.snapshot = name
+	return nil
+}
+
+// loadSnapshot reverts the emulator to the state at the time the last snapshot was created.
+func (e *devEmulator) loadSnapshot() error {
+	if e.snapshot == "" {
+		return fmt.Errorf("no snapshot of the emulator state was created")
+	}
+
+	err := e.server.Emulator().LoadSnapshot(e.snapshot)
+	if err != nil {
+		return err
+	}
+
+	e.loaded = e.snapshot
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package super
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+func Test_EmulatorConfig(t *testing.T) {
+	_, state, _ := util.TestMocks(t)
+
+	service, err := state.EmulatorServiceAccount()
+	require.NoError(t, err)
+
+	conf, err := emulatorConfig(service, "")
+	require.NoError(t, err)
+
+	privateKey, err := service.Key.PrivateKey()
+	require.NoError(t, err)
+
+	assert.Equal(t, (*privateKey).String(), conf.ServicePrivateKey.String())
+	assert.Equal(t, service.Key.SigAlgo(), conf.ServiceKeySigAlgo)
+	assert.Equal(t, service.Key.HashAlgo(), conf.ServiceKeyHashAlgo)
+	assert.Equal(t, uint64(emulatorTransactionGasLimit), conf.TransactionMaxGasLimit)
+	assert.Equal(t, "1000000000.00000000", conf.GenesisTokenSupply.String())
+	assert.True(t, conf.StorageLimitEnabled)
+	assert.NotZero(t, conf.MinimumStorageReservation)
+	assert.NotZero(t, conf.StorageMBPerFLOW)
+	assert.True(t, conf.ContractRemovalEnabled)
+	assert.False(t, conf.Snapshot)
+
+	conf, err = emulatorConfig(service, "/tmp/flow-dev")
+	require.NoError(t, err)
+	assert.True(t, conf.Snapshot)
+	assert.Equal(t, "/tmp/flow-dev", conf.SqliteURL)
+}
@@ -23,6 +23,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -37,6 +38,7 @@ const (
 	scriptDir      = "scripts"
 	transactionDir = "transactions"
 	cadenceExt     = ".cdc"
+	argsExt        = ".args.json"
 	created        = 1
 	removed        = 2
 	changed        = 3
@@ -53,6 +55,15 @@ type contractChange struct {
 	path    string
 	oldPath string
 	account string
+	// args is set if the initialization arguments of the contract changed instead of the contract code.
+	args bool
+}
+
+// codeChange is a change of a script or transaction file.
+type codeChange struct {
+	status  int
+	path    string
+	oldPath string
 }
 
 func newProjectFiles(projectPath string) *projectFiles {
@@ -115,14 +126,27 @@ func (f *projectFiles) transactions() ([]string, error) {
 	return f.getCadenceFilepaths(transactionDir)
 }
 
-// watch for file changes in the contract folder and signal any changes through channel.
+// watch for file changes in the contract, script and transaction folders and signal any changes through channel.
 //
-// This function returns two channels, accountChange which reports any changes on the accounts folders and
-// contractChange which reports any changes to the contract files.
-func (f *projectFiles) watch() (<-chan accountChange, <-chan contractChange, error) {
+// This function returns three channels, accountChange which reports any changes on the accounts folders,
+// contractChange which reports any changes to the contract files and codeChange which reports any changes
+// to the script and transaction files.
+func (f *projectFiles) watch() (<-chan accountChange, <-chan contractChange, <-chan codeChange, error) {
 	err := f.watcher.AddRecursive(filepath.Join(f.cadencePath, contractDir))
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "add recursive files failed")
+		return nil, nil, nil, errors.Wrap(err, "add recursive files failed")
+	}
+
+	for _, dir := range []string{scriptDir, transactionDir} {
+		dir = filepath.Join(f.cadencePath, dir)
+		if _, err := os.Stat(dir); err != nil { // scripts and transactions are optional
+			continue
+		}
+
+		err = f.watcher.AddRecursive(dir)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "add recursive files failed")
+		}
 	}
 
 	go func() {
@@ -134,6 +158,7 @@ func (f *projectFiles) watch() (<-chan accountChange, <-chan contractChange, err
 
 	accounts := make(chan accountChange)
 	contracts := make(chan contractChange)
+	code := make(chan codeChange)
 
 	go func() {
 		status := map[watcher.Op]int{
@@ -151,6 +176,27 @@ func (f *projectFiles) watch() (<-chan accountChange, <-chan contractChange, err
 					continue
 				}
 
+				if dir := projectDir(rel); dir == scriptDir || dir == transactionDir {
+					if event.IsDir() || filepath.Ext(rel) != cadenceExt {
+						continue
+					}
+
+					oldPath := ""
+					if event.Op == watcher.Rename {
+						oldPath, err = f.relProjectPath(event.OldPath)
+						if err != nil {
+							continue
+						}
+					}
+
+					code <- codeChange{
+						status:  status[event.Op],
+						path:    rel,
+						oldPath: oldPath,
+					}
+					continue
+				}
+
 				name, containsAccount := accountFromPath(rel)
 				if event.IsDir() && containsAccount {
 					// TODO(sideninja) handle moving of files
@@ -161,6 +207,21 @@ func (f *projectFiles) watch() (<-chan accountChange, <-chan contractChange, err
 					continue
 				}
 
+				if strings.HasSuffix(rel, argsExt) { // changed arguments require the contract to be deployed again
+					contract := strings.TrimSuffix(rel, argsExt) + cadenceExt
+					if _, err := os.Stat(filepath.Join(filepath.Dir(f.cadencePath), contract)); err != nil {
+						continue
+					}
+
+					contracts <- contractChange{
+						status:  changed,
+						path:    contract,
+						account: name,
+						args:    true,
+					}
+					continue
+				}
+
 				if filepath.Ext(rel) != cadenceExt { // skip any non cadence files
 					continue
 				}
@@ -180,6 +241,7 @@ func (f *projectFiles) watch() (<-chan accountChange, <-chan contractChange, err
 					account: name,
 				}
 			case <-f.watcher.Closed:
+				close(code)
 				close(contracts)
 				close(accounts)
 				return
@@ -187,7 +249,7 @@ func (f *projectFiles) watch() (<-chan accountChange, <-chan contractChange, err
 		}
 	}()
 
-	return accounts, contracts, nil
+	return accounts, contracts, code, nil
 }
 
 // getFilePaths returns a list of only Cadence files that are inside the provided directory.
@@ -224,6 +286,19 @@ func (f *projectFiles) relProjectPath(file string) (string, error) {
 	return rel, nil
 }
 
+// argsFilepath returns the path of the file containing initialization arguments for the contract on the provided path,
+// eg. a contract cadence/contracts/Foo.cdc has arguments in cadence/contracts/Foo.args.json.
+func argsFilepath(path string) string {
+	return strings.TrimSuffix(path, cadenceExt) + argsExt
+}
+
+// projectDir returns the folder of the project path inside the cadence folder,
+// eg. a path cadence/scripts/foo/bar.cdc is in the scripts folder.
+func projectDir(path string) string {
+	path = strings.TrimPrefix(filepath.ToSlash(path), cadenceDir+"/")
+	return strings.SplitN(path, "/", 2)[0]
+}
+
 // accountFromPath returns the account name from provided path if possible, otherwise returns empty and false.
 //
 // Account name can be extracted from path when the contract folder contains another folder, that in our syntax indicates account name.
@@ -61,3 +61,16 @@ func Test_RelativeProjectPath(t *testing.T) {
 		assert.Equal(t, filepath.FromSlash(test[1]), rel, fmt.Sprintf("test %d failed", i))
 	}
 }
+
+func Test_ProjectDir(t *testing.T) {
+	paths := [][]string{ // first is path, second is project folder
+		{"cadence/contracts/alice/foo.cdc", "contracts"},
+		{"cadence/scripts/foo.cdc", "scripts"},
+		{"cadence/transactions/alice/foo.cdc", "transactions"},
+		{"scripts/foo.cdc", "scripts"},
+	}
+
+	for i, test := range paths {
+		assert.Equal(t, test[1], projectDir(filepath.FromSlash(test[0])), fmt.Sprintf("test %d failed", i))
+	}
+}
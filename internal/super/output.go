@@ -26,19 +26,18 @@ import (
 	"os"
 	sysExec "os/exec"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"golang.org/x/exp/maps"
 
-	"github.com/onflow/flowkit"
 	"github.com/onflow/flowkit/output"
-	flowkitProject "github.com/onflow/flowkit/project"
 )
 
-func printDeployment(deployed []*flowkitProject.Contract, err error, contractPathNames map[string]string) {
+func printDeployment(deployed []*deployedContract, err error, contractPathNames map[string]string, keepState bool) {
 	clearScreen()
-	fmt.Println(helpBanner())
+	fmt.Println(helpBanner(keepState))
 
 	if err != nil {
 		fmt.Println(errorBanner())
@@ -50,7 +49,47 @@ func printDeployment(deployed []*flowkitProject.Contract, err error, contractPat
 	fmt.Println(successfulDeployment(deployed))
 }
 
-func successfulDeployment(deployed []*flowkitProject.Contract) string {
+func printChecks(checks map[string]*codeCheck) {
+	if len(checks) == 0 {
+		return
+	}
+
+	fmt.Println(output.Bold("Scripts and transactions:"))
+	fmt.Println(codeChecks(checks))
+}
+
+func codeChecks(checks map[string]*codeCheck) string {
+	var out bytes.Buffer
+
+	paths := maps.Keys(checks)
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		check := checks[path]
+
+		if check.err != nil {
+			out.WriteString(fmt.Sprintf("%s %s\n", output.ErrorEmoji(), output.Italic(path)))
+			out.WriteString(fmt.Sprintf("%s\n", output.Red(check.err.Error())))
+			continue
+		}
+
+		switch {
+		case check.value != nil:
+			out.WriteString(fmt.Sprintf("%s %s  Result: %s\n", output.OkEmoji(), output.Italic(path), check.value))
+		case check.needsArguments:
+			out.WriteString(fmt.Sprintf(
+				"%s %s  Checked, the script requires arguments so run it with 'flow scripts execute %s [arguments]'\n",
+				output.OkEmoji(), output.Italic(path), path,
+			))
+		default:
+			out.WriteString(fmt.Sprintf("%s %s  Checked\n", output.OkEmoji(), output.Italic(path)))
+		}
+	}
+
+	return out.String()
+}
+
+func successfulDeployment(deployed []*deployedContract) string {
 	var out bytes.Buffer
 	okFaces := []string{"😎", "🤩", "🤠", "🤖", "🤡", "👽", "👾", "🥸", "🧐", "👻", "💩", "🤓", "🥳", "🤑", "😍", "👿"}
 
@@ -61,9 +100,15 @@ func successfulDeployment(deployed []*flowkitProject.Contract) string {
 		if deployOut[key] == nil {
 			deployOut[key] = make([]string, 0)
 		}
+		status := "unchanged"
+		if deploy.duration > 0 && deploy.updated {
+			status = fmt.Sprintf("updated in %s", deploy.duration.Round(time.Millisecond))
+		} else if deploy.duration > 0 {
+			status = fmt.Sprintf("deployed in %s", deploy.duration.Round(time.Millisecond))
+		}
 		deployOut[key] = append(
 			deployOut[key],
-			fmt.Sprintf("    |- %s  %s", output.Bold(output.Magenta(deploy.Name)), output.Italic(deploy.Location())),
+			fmt.Sprintf("    |- %s  %s  %s", output.Bold(output.Magenta(deploy.Name)), output.Italic(deploy.Location()), status),
 		)
 	}
 
@@ -80,12 +125,6 @@ func successfulDeployment(deployed []*flowkitProject.Contract) string {
 func failureDeployment(err error, contractPathNames map[string]string) string {
 	var out bytes.Buffer
 
-	// handle emulator not allowing overwriting contracts
-	if strings.Contains(err.Error(), "cannot overwrite existing contract with name") {
-		out.WriteString(output.ErrorEmoji() + output.Red(" Cannot overwrite existing contract, that means you are running the emulator without the --contract-removal flag.\n"))
-		out.WriteString(output.TryEmoji() + " Please restart the emulator with the --contract-removal flag present as we are required to continuously update contracts as you work.")
-	}
-
 	// handle import path errors with helpful message
 	importRegex := regexp.MustCompile(`import from (\w*) could not be found: (\w*), make sure import path is correct`)
 	if importRegex.MatchString(err.Error()) {
@@ -110,7 +149,7 @@ func failureDeployment(err error, contractPathNames map[string]string) string {
 	}
 
 	// handle cadence runtime errors
-	var deployErr *flowkit.ProjectDeploymentError
+	var deployErr *deploymentError
 	if errors.As(err, &deployErr) {
 		out.WriteString(output.ErrorEmoji() + " Error deploying your project. Runtime error encountered which means your code is incorrect, check details bellow. \n\n")
 
@@ -118,14 +157,22 @@ func failureDeployment(err error, contractPathNames map[string]string) string {
 			out.WriteString(output.Bold(fmt.Sprintf("%s Errors:\n", name)))
 
 			if strings.Contains(err.Error(), "invalid argument count, too few arguments") {
-				out.WriteString(output.Red(
-					"Deploying a contract failed because it requires initialization arguments. We currently don't support passing initialization arguments, so we suggest you hardcode the initialization arguments in the init function to be used during development.\n\n",
-				))
+				argsPath := argsFilepath(fmt.Sprintf("%s%s", name, cadenceExt))
+				for p, n := range contractPathNames {
+					if name == n {
+						argsPath = argsFilepath(p)
+					}
+				}
+
+				out.WriteString(output.Red(fmt.Sprintf(
+					"Deploying a contract failed because it requires initialization arguments. Add the arguments in JSON-Cadence format to the %s file or to the contract deployment in the flow.json configuration.\n\n",
+					argsPath,
+				)))
 				continue
 			}
 
 			// remove transaction error as it confuses developer, the only important part is the actual code
-			removeDeployOuput := regexp.MustCompile(`(?s)(failed to deploy.*contracts\.add[^\n]*\n[^\n]*\n\nerror: )`)
+			removeDeployOuput := regexp.MustCompile(`(?s)(failed to (deploy|update).*contracts\.(add|update)[^\n]*\n[^\n]*\n\nerror: )`)
 			out.WriteString(output.Red(removeDeployOuput.ReplaceAllString(err.Error(), "")))
 		}
 		return out.String()
@@ -134,11 +181,16 @@ func failureDeployment(err error, contractPathNames map[string]string) string {
 	return err.Error()
 }
 
-func helpBanner() string {
+func helpBanner(keepState bool) string {
 	var out bytes.Buffer
 	out.WriteString(output.Italic("The development environment will watch your Cadence files and automatically keep your project updated on the emulator.\n"))
 	out.WriteString(output.Italic("Please add your contracts in the contracts folder. Read more about it here: https://developers.flow.com/tools/flow-cli/super-commands\n"))
-	out.WriteString(output.Italic("Be aware that resources stored in accounts might no longer be valid after contract code changes.\n\n"))
+	out.WriteString(output.Italic("Scripts and transactions in the scripts and transactions folders are checked against your deployed contracts on every change.\n"))
+	if keepState {
+		out.WriteString(output.Italic("Contracts are updated in place to keep the data stored in accounts, if an update is incompatible you can roll back to the state before the change.\n\n"))
+	} else {
+		out.WriteString(output.Italic("Be aware that resources stored in accounts might no longer be valid after contract code changes, use --keep-state to keep them.\n\n"))
+	}
 	return out.String()
 }
 
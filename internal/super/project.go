@@ -21,12 +21,14 @@ package super
 import (
 	"context"
 
+	"github.com/onflow/cadence"
 	"github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/crypto"
 	"github.com/pkg/errors"
 
 	"github.com/onflow/flowkit"
 	"github.com/onflow/flowkit/accounts"
+	"github.com/onflow/flowkit/arguments"
 	"github.com/onflow/flowkit/config"
 	flowkitProject "github.com/onflow/flowkit/project"
 
@@ -42,6 +44,7 @@ func newProject(
 	flow flowkit.Services,
 	state *flowkit.State,
 	files *projectFiles,
+	snapshots snapshotter,
 ) (*project, error) {
 	proj := &project{
 		service:        &serviceAccount,
@@ -49,6 +52,12 @@ func newProject(
 		state:          state,
 		projectFiles:   files,
 		pathNameLookup: make(map[string]string),
+		checks:         make(map[string]*codeCheck),
+		deploymentArgs: make(map[string][]cadence.Value),
+		snapshots:      snapshots,
+		updates:        make(map[string]*contractUpdate),
+		contractErrs:   make(map[string]error),
+		rollbackPrompt: rollbackPrompt,
 	}
 
 	if err := proj.projectFiles.exist(); err != nil {
@@ -64,6 +73,17 @@ type project struct {
 	state          *flowkit.State
 	projectFiles   *projectFiles
 	pathNameLookup map[string]string
+	graph          *contractGraph
+	deployed       []*deployedContract
+	deployErr      error
+	checks         map[string]*codeCheck
+	deploymentArgs map[string][]cadence.Value
+	// snapshots of the emulator state, contracts are only updated in place if they are set
+	snapshots      snapshotter
+	updates        map[string]*contractUpdate
+	rollbackPrompt func(name string, reason string) bool
+	// contractErrs of the changed contracts which couldn't be added to the deployment, by the contract name
+	contractErrs map[string]error
 }
 
 // startup cleans the state and then rebuilds it from the current folder state.
@@ -73,6 +93,7 @@ func (p *project) startup() error {
 		return err
 	}
 
+	p.keepDeploymentArgs()
 	p.cleanState()
 	err = p.addAccount(defaultAccount)
 	if err != nil {
@@ -102,14 +123,76 @@ func (p *project) startup() error {
 	}
 
 	p.deploy()
+	err = p.checkAllCode()
+	if err != nil {
+		return err
+	}
+	p.render()
 
 	return p.state.SaveDefault()
 }
 
-// deploys all the contracts found in the state configuration.
-func (p *project) deploy() {
-	deployed, err := p.flow.DeployProject(context.Background(), flowkit.UpdateExistingContract(true))
-	printDeployment(deployed, err, p.pathNameLookup)
+// render the dashboard with the deployment status and the results of the script and transaction checks.
+func (p *project) render() {
+	printDeployment(p.deployed, p.deployErr, p.pathNameLookup, p.snapshots != nil)
+	printChecks(p.checks)
+}
+
+// checkAllCode checks all the scripts and transactions in the project, which is needed each time the contracts change.
+func (p *project) checkAllCode() error {
+	scripts, err := p.projectFiles.scripts()
+	if err != nil {
+		return err
+	}
+
+	transactions, err := p.projectFiles.transactions()
+	if err != nil {
+		return err
+	}
+
+	p.checks = make(map[string]*codeCheck)
+	for _, path := range scripts {
+		p.checks[path] = p.checkCode(path, scriptKind)
+	}
+	for _, path := range transactions {
+		p.checks[path] = p.checkCode(path, transactionKind)
+	}
+
+	return nil
+}
+
+// updateCheck checks the changed script or transaction and updates the check results.
+func (p *project) updateCheck(code codeChange) {
+	kind := scriptKind
+	if projectDir(code.path) == transactionDir {
+		kind = transactionKind
+	}
+
+	switch code.status {
+	case removed:
+		delete(p.checks, code.path)
+	case renamed:
+		delete(p.checks, code.oldPath)
+		p.checks[code.path] = p.checkCode(code.path, kind)
+	default:
+		p.checks[code.path] = p.checkCode(code.path, kind)
+	}
+}
+
+// keepDeploymentArgs stores the initialization arguments of the emulator deployments from the configuration,
+// so they are used when the deployments are rebuilt.
+func (p *project) keepDeploymentArgs() {
+	for _, deployment := range *p.state.Deployments() {
+		if deployment.Network != emulator {
+			continue
+		}
+
+		for _, contract := range deployment.Contracts {
+			if len(contract.Args) > 0 {
+				p.deploymentArgs[contract.Name] = contract.Args
+			}
+		}
+	}
 }
 
 // cleanState of existing contracts, deployments and non-service accounts as we will build it again.
@@ -149,7 +232,7 @@ func (p *project) cleanState() {
 
 // watch project files and update the state accordingly.
 func (p *project) watch() error {
-	accountChanges, contractChanges, err := p.projectFiles.watch()
+	accountChanges, contractChanges, codeChanges, err := p.projectFiles.watch()
 	if err != nil {
 		return errors.Wrap(err, "error watching files")
 	}
@@ -171,29 +254,50 @@ func (p *project) watch() error {
 				contract.account = defaultAccount
 			}
 
+			deployed := false
 			switch contract.status {
 			case created:
-				_ = p.addContract(contract.path, contract.account)
+				p.addChangedContract(contract.path, contract.account)
 			case changed:
-				// Remove contract before updating
+				if p.snapshots != nil { // keep the stored data by updating the contract in place
+					deployed, err = p.updateContract(contract)
+					if err != nil {
+						return err
+					}
+					break
+				}
+
+				// Remove contract and the contracts importing it before updating
 				// This is so one can develop without having to restart the emulator when hitting contract upgrade issues
 				// See: https://developers.flow.com/cadence/language/contract-updatability
-				err = p.removeContract(contract.path, contract.account)
+				err = p.undeploy(contract.path)
 				if err != nil {
 					return err
 				}
-				_ = p.addContract(contract.path, contract.account)
+				p.removeContract(contract.path, contract.account)
+				p.addChangedContract(contract.path, contract.account)
 			case renamed:
 				p.renameContract(contract.oldPath, contract.path)
 			case removed:
 				// TODO(sideninja) what if contract contains invalid code and then we want to remove it
-				err = p.removeContract(contract.path, contract.account)
+				err = p.undeploy(contract.path)
 				if err != nil {
 					return err
 				}
+				p.removeContract(contract.path, contract.account)
 			}
 
-			p.deploy()
+			if !deployed {
+				p.deploy()
+			}
+			err = p.checkAllCode()
+			if err != nil {
+				return errors.Wrap(err, "failed checking scripts and transactions")
+			}
+			p.render()
+		case code := <-codeChanges:
+			p.updateCheck(code)
+			p.render()
 		}
 
 		err = p.state.SaveDefault()
@@ -292,25 +396,74 @@ func (p *project) addContract(
 	}
 
 	if contract.Aliases.ByNetwork(emulator) == nil { // only add if not existing emulator alias
+		args, err := p.contractArgs(path, name)
+		if err != nil {
+			return err
+		}
+
 		p.state.Deployments().
 			ByAccountAndNetwork(account, emulator).
 			AddContract(config.ContractDeployment{
 				Name: contract.Name,
+				Args: args,
 			})
 	}
 
 	p.state.Contracts().AddOrUpdate(contract)
+	delete(p.contractErrs, name)
 	return nil
 }
 
+// addChangedContract adds the contract like addContract, but an error is reported with the deployment of the
+// contract instead of stopping flow dev, since the contract files are often invalid while they are being edited.
+func (p *project) addChangedContract(path string, account string) {
+	err := p.addContract(path, account)
+	if err == nil {
+		return
+	}
+
+	name, nameErr := p.contractName(path)
+	if nameErr != nil {
+		return // invalid contract code is reported by the code checks
+	}
+
+	if p.contractErrs == nil {
+		p.contractErrs = make(map[string]error)
+	}
+	p.contractErrs[name] = err
+}
+
+// contractArgs returns the initialization arguments of the contract.
+//
+// Arguments are read from the arguments file next to the contract, if one exists, otherwise
+// the arguments from the deployment in the configuration are used.
+func (p *project) contractArgs(path string, name string) ([]cadence.Value, error) {
+	argsPath := argsFilepath(path)
+	if _, err := p.state.ReaderWriter().Stat(argsPath); err != nil {
+		return p.deploymentArgs[name], nil
+	}
+
+	content, err := p.state.ReadFile(argsPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load contract initialization arguments")
+	}
+
+	args, err := arguments.ParseJSON(string(content))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid initialization arguments in %s", argsPath)
+	}
+
+	return args, nil
+}
+
 // removeContract from state configuration.
 func (p *project) removeContract(
 	path string,
 	accountName string,
-) error {
+) {
 	name, err := p.contractName(path)
 	if err != nil {
-		return errors.Wrap(err, "failed to remove contract")
+		return
 	}
 
 	if accountName == "" {
@@ -322,19 +475,8 @@ func (p *project) removeContract(
 			ByAccountAndNetwork(accountName, emulator).
 			RemoveContract(name) // we might delete account first
 		_ = p.state.Contracts().Remove(name)
-
-		acc, err := p.state.Accounts().ByName(accountName)
-		if err != nil {
-			return err
-		}
-
-		_, err = p.flow.RemoveContract(context.Background(), acc, name)
-		if err != nil {
-			return err
-		}
 	}
-
-	return nil
+	delete(p.contractErrs, name)
 }
 
 // renameContract and update the location in the state
@@ -0,0 +1,82 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package super
+
+import (
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flowkit/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+func Test_ContractArgs(t *testing.T) {
+	_, state, rw := util.TestMocks(t)
+
+	state.Deployments().AddOrUpdate(config.Deployment{
+		Network: emulator,
+		Account: defaultAccount,
+		Contracts: []config.ContractDeployment{{
+			Name: "Foo",
+			Args: []cadence.Value{cadence.String("configured")},
+		}, {
+			Name: "Bar",
+			Args: []cadence.Value{cadence.String("configured")},
+		}},
+	})
+
+	require.NoError(t, rw.WriteFile(
+		"cadence/contracts/Bar.args.json",
+		[]byte(`[{"type": "String", "value": "file"}, {"type": "Int", "value": "1"}]`),
+		0644,
+	))
+	require.NoError(t, rw.WriteFile("cadence/contracts/Invalid.args.json", []byte(`[{"type": "Int"}]`), 0644))
+
+	p := &project{
+		state:          state,
+		deploymentArgs: make(map[string][]cadence.Value),
+	}
+	p.keepDeploymentArgs()
+
+	t.Run("From configuration", func(t *testing.T) {
+		args, err := p.contractArgs("cadence/contracts/Foo.cdc", "Foo")
+		require.NoError(t, err)
+		assert.Equal(t, []cadence.Value{cadence.String("configured")}, args)
+	})
+
+	t.Run("From arguments file", func(t *testing.T) {
+		args, err := p.contractArgs("cadence/contracts/Bar.cdc", "Bar")
+		require.NoError(t, err)
+		assert.Equal(t, []cadence.Value{cadence.String("file"), cadence.NewInt(1)}, args)
+	})
+
+	t.Run("No arguments", func(t *testing.T) {
+		args, err := p.contractArgs("cadence/contracts/Zoo.cdc", "Zoo")
+		require.NoError(t, err)
+		assert.Nil(t, args)
+	})
+
+	t.Run("Invalid arguments file", func(t *testing.T) {
+		_, err := p.contractArgs("cadence/contracts/Invalid.cdc", "Invalid")
+		assert.ErrorContains(t, err, "invalid initialization arguments in cadence/contracts/Invalid.args.json")
+	})
+}
@@ -0,0 +1,76 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"fmt"
+
+	"github.com/onflow/flowkit"
+
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+// testConfigKey is the flow.json section configuring flow test.
+const testConfigKey = "test"
+
+// testConfig is the flow test configuration in flow.json, for example:
+//
+//	"test": {
+//		"ignore": ["cadence/legacy/", "slow_*.cdc"],
+//		"coverageThresholds": {
+//			"FooContract": 90
+//		}
+//	}
+type testConfig struct {
+	// Ignore are patterns of files and directories excluded from test discovery, patterns containing
+	// a slash match the path relative to the project root and other patterns match the base name
+	Ignore []string `json:"ignore,omitempty"`
+	// CoverageThresholds are the minimum coverage percentages by contract name
+	CoverageThresholds map[string]float64 `json:"coverageThresholds,omitempty"`
+}
+
+// loadTestConfig reads the flow test configuration from the configuration files on the paths,
+// an empty configuration is returned if there is none.
+func loadTestConfig(rw flowkit.ReaderWriter, paths []string) (*testConfig, error) {
+	conf := &testConfig{}
+	if _, err := util.ReadConfigSection(rw, paths, testConfigKey, conf); err != nil {
+		return nil, err
+	}
+
+	for name, minimum := range conf.CoverageThresholds { // nolint:maprange
+		if minimum < 0 || minimum > 100 {
+			return nil, fmt.Errorf("invalid coverage threshold %v of %s in the %s configuration, it must be a percentage", minimum, name, testConfigKey)
+		}
+	}
+
+	return conf, nil
+}
+
+// coverageThresholds returns the per-contract minimum coverage of the configuration, overridden by the provided thresholds.
+func (c *testConfig) coverageThresholds(overrides map[string]float64) map[string]float64 {
+	thresholds := make(map[string]float64, len(c.CoverageThresholds)+len(overrides))
+	for name, minimum := range c.CoverageThresholds { // nolint:maprange
+		thresholds[name] = minimum
+	}
+	for name, minimum := range overrides { // nolint:maprange
+		thresholds[name] = minimum
+	}
+
+	return thresholds
+}
@@ -0,0 +1,192 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/common"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/config"
+
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+// Name of the row reporting the coverage of all locations when it is below the minimum.
+const totalCoverageName = "total"
+
+// coverageShortfall is a contract, or the total, whose coverage is below its minimum.
+type coverageShortfall struct {
+	Name        string
+	Coverage    float64
+	Minimum     float64
+	MissedLines []int
+}
+
+// writeCoverage writes the coverage report in the format given by the extension of the filename.
+//
+// HTML reports are written as an index at the filename, linking to a page for each file
+// in a directory named after the index.
+func writeCoverage(
+	report *runtime.CoverageReport,
+	filename string,
+	rw flowkit.ReaderWriter,
+	contracts *config.Contracts,
+) error {
+	files := make(map[string][]byte)
+	var err error
+
+	ext := filepath.Ext(filename)
+	if ext == ".json" {
+		files[filename], err = json.MarshalIndent(report, "", "  ")
+	} else if ext == ".lcov" {
+		files[filename], err = report.MarshalLCOV()
+	} else if ext == ".html" {
+		files, err = coverageHTML(report, filename, rw, contracts)
+	} else {
+		return fmt.Errorf("given format: %v, only .json, .lcov and .html are supported", ext)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error serializing coverage report: %w", err)
+	}
+
+	for path, file := range files { // nolint:maprange
+		if dir := filepath.Dir(path); dir != "." {
+			if err := rw.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("error writing coverage report file: %w", err)
+			}
+		}
+
+		if err := rw.WriteFile(path, file, 0644); err != nil {
+			return fmt.Errorf("error writing coverage report file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// parseCoverageThresholds parses per-contract minimum coverage provided in the format Contract=percentage.
+func parseCoverageThresholds(values []string) (map[string]float64, error) {
+	thresholds := make(map[string]float64, len(values))
+
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+
+		name, percentage, found := strings.Cut(value, "=")
+		minimum, err := strconv.ParseFloat(strings.TrimSuffix(percentage, "%"), 64)
+		if !found || name == "" || err != nil || minimum < 0 || minimum > 100 {
+			return nil, fmt.Errorf("invalid coverage threshold %s, use the format Contract=percentage", value)
+		}
+
+		thresholds[name] = minimum
+	}
+
+	return thresholds, nil
+}
+
+// checkCoverage returns the contracts whose coverage is below their threshold, or below the minimum if they
+// have none, followed by the total if it is below the minimum. Scripts and transactions only count towards the total.
+func checkCoverage(report *runtime.CoverageReport, minimum float64, thresholds map[string]float64) []coverageShortfall {
+	shortfalls := make([]coverageShortfall, 0)
+
+	for location, coverage := range report.Coverage { // nolint:maprange
+		addressLocation, ok := location.(common.AddressLocation)
+		if !ok {
+			continue
+		}
+
+		contractMinimum, ok := thresholds[addressLocation.Name]
+		if !ok {
+			contractMinimum = minimum
+		}
+
+		percentage := coveragePercentage(coverage.CoveredLines(), coverage.Statements)
+		if percentage < contractMinimum {
+			shortfalls = append(shortfalls, coverageShortfall{
+				Name:        addressLocation.Name,
+				Coverage:    percentage,
+				Minimum:     contractMinimum,
+				MissedLines: coverage.MissedLines(),
+			})
+		}
+	}
+
+	sort.Slice(shortfalls, func(i, j int) bool {
+		return shortfalls[i].Name < shortfalls[j].Name
+	})
+
+	if total := coveragePercentage(report.Hits(), report.Statements()); total < minimum {
+		shortfalls = append(shortfalls, coverageShortfall{
+			Name:     totalCoverageName,
+			Coverage: total,
+			Minimum:  minimum,
+		})
+	}
+
+	return shortfalls
+}
+
+// coveragePercentage matches runtime.LocationCoverage.Percentage, hits are capped at the statements.
+func coveragePercentage(hits, statements int) float64 {
+	if statements == 0 {
+		return 100
+	}
+	if hits > statements {
+		hits = statements
+	}
+
+	return 100 * float64(hits) / float64(statements)
+}
+
+// formatShortfalls renders the shortfalls as a table with the uncovered lines of each contract.
+func formatShortfalls(shortfalls []coverageShortfall) string {
+	var b bytes.Buffer
+	writer := util.CreateTabWriter(&b)
+
+	_, _ = fmt.Fprintf(writer, "Contract\tCoverage\tMinimum\tUncovered Lines\n")
+	for _, shortfall := range shortfalls {
+		lines := make([]string, 0, len(shortfall.MissedLines))
+		for _, line := range shortfall.MissedLines {
+			lines = append(lines, strconv.Itoa(line))
+		}
+
+		_, _ = fmt.Fprintf(
+			writer,
+			"%s\t%.1f%%\t%.1f%%\t%s\n",
+			shortfall.Name,
+			shortfall.Coverage,
+			shortfall.Minimum,
+			strings.Join(lines, ", "),
+		)
+	}
+	_ = writer.Flush()
+
+	return b.String()
+}
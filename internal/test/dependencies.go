@@ -0,0 +1,250 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/cadence/runtime/parser"
+
+	"github.com/onflow/flowkit"
+
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+// The directory the dependency manager installs contracts to, as imports/<address>/<Name>.cdc.
+const importsDirectory = "imports"
+
+// The accounts created by the test framework for deploying contracts, dependencies without a
+// testing alias are assigned these accounts starting from the last one, so they don't move
+// when aliases are added for project contracts, which usually start from the first ones.
+const (
+	firstTestingAccount = 0x05
+	lastTestingAccount  = 0x18
+)
+
+// testDependencies are the contracts installed by the dependency manager.
+//
+// Each account the dependencies were installed from gets a testing account, so contracts deployed
+// together on-chain are also deployed together in tests. Core contracts are provided by the test framework.
+type testDependencies struct {
+	// locations are the files of the installed contracts by name
+	locations map[string]string
+	// installed are the names of the contracts by the address and contract name they were installed from
+	installed map[common.AddressLocation]string
+	// aliases are the testing aliases assigned to dependencies without one
+	aliases map[string]common.Address
+}
+
+type installedContract struct {
+	name string
+	// contractName is the name of the contract at the address, it differs from the name of a renamed dependency
+	contractName string
+	address      common.Address
+	location     string
+	core         bool
+}
+
+// newTestDependencies finds the dependencies in the configuration and the imports directory
+// and assigns testing aliases to the ones without an alias in the configured contracts.
+func newTestDependencies(state *flowkit.State, configured map[string]common.Address) (*testDependencies, error) {
+	contracts, err := installedContracts(state)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := &testDependencies{
+		locations: make(map[string]string, len(contracts)),
+		installed: make(map[common.AddressLocation]string, len(contracts)),
+		aliases:   make(map[string]common.Address),
+	}
+
+	used := make(map[common.Address]bool, len(configured))
+	for _, address := range configured { // nolint:maprange
+		used[address] = true
+	}
+
+	accounts := make(map[common.Address]common.Address)
+	next := lastTestingAccount
+	for _, contract := range contracts {
+		deps.locations[contract.name] = contract.location
+		deps.installed[common.AddressLocation{Address: contract.address, Name: contract.contractName}] = contract.name
+
+		if _, ok := configured[contract.name]; ok || contract.core {
+			continue
+		}
+
+		account, ok := accounts[contract.address]
+		for !ok {
+			if next < firstTestingAccount {
+				return nil, fmt.Errorf(
+					"not enough testing accounts for the dependencies, add a testing alias for %s",
+					contract.name,
+				)
+			}
+			var candidate common.Address
+			candidate[len(candidate)-1] = byte(next)
+			next--
+			if !used[candidate] {
+				account, ok = candidate, true
+				accounts[contract.address] = account
+			}
+		}
+
+		deps.aliases[contract.name] = account
+	}
+
+	return deps, nil
+}
+
+// installedContracts returns the dependencies of the configuration followed by contracts in the imports
+// directory which are not part of it, ordered by the address they were installed from and their name.
+func installedContracts(state *flowkit.State) ([]installedContract, error) {
+	found := make(map[string]installedContract)
+	locations := make(map[string]bool)
+
+	for _, dep := range *state.Dependencies() {
+		_, core := util.CoreContractByAddress(dep.Source.ContractName, dep.Source.NetworkName, dep.Source.Address)
+		location := filepath.Join(importsDirectory, dep.Source.Address.String(), fmt.Sprintf("%s.cdc", dep.Source.ContractName))
+		if contract, err := state.Contracts().ByName(dep.Name); err == nil {
+			location = contract.Location
+		}
+		locations[filepath.Clean(location)] = true
+
+		found[dep.Name] = installedContract{
+			name:         dep.Name,
+			contractName: dep.Source.ContractName,
+			address:      common.Address(dep.Source.Address),
+			location:     location,
+			core:         core,
+		}
+	}
+
+	rw := state.ReaderWriter()
+	if _, err := rw.Stat(importsDirectory); err == nil {
+		err := walk(rw, importsDirectory, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || filepath.Ext(path) != ".cdc" {
+				return nil
+			}
+
+			dir, file := filepath.Split(path)
+			if filepath.Clean(filepath.Dir(filepath.Clean(dir))) != importsDirectory {
+				return nil
+			}
+			address, err := common.HexToAddress(filepath.Base(dir))
+			if err != nil {
+				return nil
+			}
+
+			name := strings.TrimSuffix(file, ".cdc")
+			if _, ok := found[name]; ok || locations[filepath.Clean(path)] {
+				return nil
+			}
+
+			found[name] = installedContract{
+				name:         name,
+				contractName: name,
+				address:      address,
+				location:     path,
+				core:         isCoreContract(name, address),
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error reading installed dependencies: %w", err)
+		}
+	}
+
+	contracts := make([]installedContract, 0, len(found))
+	for _, contract := range found { // nolint:maprange
+		contracts = append(contracts, contract)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		if contracts[i].address != contracts[j].address {
+			return contracts[i].address.Hex() < contracts[j].address.Hex()
+		}
+		return contracts[i].name < contracts[j].name
+	})
+
+	return contracts, nil
+}
+
+// isCoreContract reports whether the contract is deployed at the address on any network.
+func isCoreContract(name string, address common.Address) bool {
+	contract, ok := util.CoreContracts[name]
+	if !ok {
+		return false
+	}
+
+	for _, coreAddress := range contract.Addresses { // nolint:maprange
+		if common.Address(coreAddress) == address {
+			return true
+		}
+	}
+
+	return false
+}
+
+// location returns the file of the installed contract.
+func (d *testDependencies) location(name string) (string, bool) {
+	location, ok := d.locations[name]
+	return location, ok
+}
+
+// rewriteImports replaces address imports of installed contracts with imports by the name they were
+// installed as, which the test framework resolves to the testing account of the contract.
+func (d *testDependencies) rewriteImports(code string) string {
+	if len(d.installed) == 0 {
+		return code
+	}
+
+	program, err := parser.ParseProgram(nil, []byte(code), parser.Config{})
+	if err != nil {
+		return code
+	}
+
+	var b strings.Builder
+	offset := 0
+	for _, declaration := range program.ImportDeclarations() {
+		location, ok := declaration.Location.(common.AddressLocation)
+		if !ok || len(declaration.Identifiers) == 0 {
+			continue
+		}
+
+		name, ok := d.installed[common.AddressLocation{Address: location.Address, Name: declaration.Identifiers[0].Identifier}]
+		if !ok {
+			continue
+		}
+
+		b.WriteString(code[offset:declaration.LocationPos.Offset])
+		b.WriteString(fmt.Sprintf("%q", name))
+		offset = declaration.EndPos.Offset + 1
+	}
+	b.WriteString(code[offset:])
+
+	return b.String()
+}
@@ -0,0 +1,165 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/onflow/flowkit"
+)
+
+// Files with this suffix are discovered as test files.
+const testFileSuffix = "_test.cdc"
+
+// Patterns ending with this suffix discover test files in all subdirectories, like Go package patterns.
+const recursivePatternSuffix = "/..."
+
+// Directories that never contain project tests.
+var defaultIgnorePatterns = []string{".git", "node_modules"}
+
+type fileWalker interface {
+	Walk(root string, walkFn filepath.WalkFunc) error
+}
+
+// discoverTestFiles returns the test files matching the arguments, all test files in the project if there are none.
+//
+// Arguments can be test files, directories, or directories followed by /... to include all subdirectories.
+// Files named explicitly are always included, discovered files are filtered by the ignore list.
+func discoverTestFiles(rw flowkit.ReaderWriter, configPaths []string, args []string) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{"." + recursivePatternSuffix}
+	}
+
+	ignorePatterns, err := readIgnorePatterns(rw, configPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool)
+	for _, arg := range args {
+		recursive := strings.HasSuffix(filepath.ToSlash(arg), recursivePatternSuffix)
+		root := filepath.Clean(strings.TrimSuffix(filepath.ToSlash(arg), recursivePatternSuffix))
+		if root == "" {
+			root = "."
+		}
+
+		info, err := rw.Stat(root)
+		if err != nil {
+			return nil, fmt.Errorf("error loading test files %s: %w", arg, err)
+		}
+
+		if !info.IsDir() {
+			found[root] = true
+			continue
+		}
+
+		err = walk(rw, root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if path != root && ignored(path, ignorePatterns) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if info.IsDir() {
+				if path != root && !recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if strings.HasSuffix(path, testFileSuffix) {
+				found[path] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error discovering test files in %s: %w", arg, err)
+		}
+	}
+
+	files := make([]string, 0, len(found))
+	for file := range found {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no test files found, test files must end with %s", testFileSuffix)
+	}
+
+	return files, nil
+}
+
+func walk(rw flowkit.ReaderWriter, root string, walkFn filepath.WalkFunc) error {
+	if walker, ok := rw.(fileWalker); ok {
+		return walker.Walk(root, walkFn)
+	}
+
+	return filepath.Walk(root, walkFn)
+}
+
+// readIgnorePatterns reads the ignore list of the flow test configuration.
+func readIgnorePatterns(rw flowkit.ReaderWriter, configPaths []string) ([]string, error) {
+	conf, err := loadTestConfig(rw, configPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := append([]string{}, defaultIgnorePatterns...)
+	for _, pattern := range conf.Ignore {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		pattern = strings.TrimPrefix(strings.TrimSuffix(pattern, "/"), "./")
+		patterns = append(patterns, strings.TrimPrefix(pattern, "/"))
+	}
+
+	return patterns, nil
+}
+
+// ignored reports whether the path matches an ignore pattern, patterns containing a slash are matched
+// against the whole path relative to the project root and other patterns against the base name.
+func ignored(path string, patterns []string) bool {
+	path = filepath.ToSlash(filepath.Clean(path))
+	base := filepath.Base(path)
+
+	for _, pattern := range patterns {
+		subject := base
+		if strings.Contains(pattern, "/") {
+			subject = path
+		}
+
+		if matched, _ := filepath.Match(pattern, subject); matched {
+			return true
+		}
+	}
+
+	return false
+}
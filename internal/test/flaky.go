@@ -0,0 +1,273 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	cdcTests "github.com/onflow/cadence-tools/test"
+
+	"github.com/onflow/flowkit"
+
+	"github.com/onflow/flow-cli/internal/util"
+)
+
+// testRuns is the outcome of a test over repeated runs and retries.
+type testRuns struct {
+	Passed int
+	Failed int
+	// Seeds are the seeds of the runs in which the test failed, empty if the tests didn't run in random order
+	Seeds []int64
+}
+
+func (t *testRuns) fail(seed int64) {
+	t.Failed++
+	if seed > 0 && (len(t.Seeds) == 0 || t.Seeds[len(t.Seeds)-1] != seed) {
+		t.Seeds = append(t.Seeds, seed)
+	}
+}
+
+// flaky reports whether the test both passed and failed.
+func (t *testRuns) flaky() bool {
+	return t.Passed > 0 && t.Failed > 0
+}
+
+// unstableTest is a test which failed at least once.
+type unstableTest struct {
+	File string
+	Name string
+	Runs *testRuns
+}
+
+// testRepeatedly runs the tests count times and merges the results, a test fails if it failed in any run.
+//
+// Each run uses a different seed, starting from the seed flag or a random one, so the failing
+// orders can be reproduced with the seed flag.
+func testRepeatedly(testFiles map[string][]byte, state *flowkit.State, flags flagsTests) (*result, error) {
+	if flags.Count <= 1 {
+		return testCode(testFiles, state, flags)
+	}
+
+	seed := flags.Seed
+	if seed <= 0 {
+		seed = int64(rand.Intn(150000)) + 1
+	}
+
+	var merged *result
+	for i := 0; i < flags.Count; i++ {
+		runFlags := flags
+		runFlags.Seed = seed + int64(i)
+
+		r, err := testCode(testFiles, state, runFlags)
+		if err != nil {
+			return nil, err
+		}
+
+		if merged == nil {
+			merged = r
+		} else {
+			merged.merge(r)
+		}
+		merged.Seeds = append(merged.Seeds, runFlags.Seed)
+	}
+
+	return merged, nil
+}
+
+// merge adds the results of another run, the first failure of each test is kept.
+func (r *result) merge(other *result) {
+	for file, otherResults := range other.Results { // nolint:maprange
+		results, ok := r.Results[file]
+		if !ok {
+			r.Results[file] = otherResults
+			continue
+		}
+
+		failures := make(map[string]cdcTests.Result, len(otherResults))
+		for _, otherResult := range otherResults {
+			if otherResult.Error != nil {
+				failures[otherResult.TestName] = otherResult
+			}
+		}
+		for i, testResult := range results {
+			if failure, ok := failures[testResult.TestName]; ok && testResult.Error == nil {
+				results[i] = failure
+			}
+		}
+	}
+
+	for file, duration := range other.Durations { // nolint:maprange
+		r.Durations[file] += duration
+	}
+
+	for file, otherDurations := range other.TestDurations { // nolint:maprange
+		durations, ok := r.TestDurations[file]
+		if !ok {
+			r.TestDurations[file] = otherDurations
+			continue
+		}
+
+		for name, duration := range otherDurations { // nolint:maprange
+			durations[name] += duration
+		}
+	}
+
+	for file, otherRuns := range other.TestRuns { // nolint:maprange
+		runs, ok := r.TestRuns[file]
+		if !ok {
+			r.TestRuns[file] = otherRuns
+			continue
+		}
+
+		for name, otherRun := range otherRuns { // nolint:maprange
+			run, ok := runs[name]
+			if !ok {
+				runs[name] = otherRun
+				continue
+			}
+			run.Passed += otherRun.Passed
+			run.Failed += otherRun.Failed
+			run.Seeds = append(run.Seeds, otherRun.Seeds...)
+		}
+	}
+
+	if r.CoverageReport != nil && other.CoverageReport != nil {
+		mergeCoverage(r.CoverageReport, other.CoverageReport)
+	}
+}
+
+// retryFailedTests runs the file again while any of its tests fails, up to the number of retries.
+//
+// Every retry runs the same tests in the same order as the first run, so a test which only fails
+// because of the tests running before it keeps failing. A failed test which passes on a retry
+// replaces its failed result, and is reported as flaky.
+func retryFailedTests(
+	rerun func() fileResult,
+	fileResult *fileResult,
+	retries int,
+	seed int64,
+) map[string]*testRuns {
+	runs := make(map[string]*testRuns, len(fileResult.results))
+	failed := make(map[string]int)
+
+	for i, testResult := range fileResult.results {
+		testRun := &testRuns{}
+		runs[testResult.TestName] = testRun
+
+		if testResult.Error == nil {
+			testRun.Passed++
+			continue
+		}
+		testRun.fail(seed)
+		failed[testResult.TestName] = i
+	}
+
+	for attempt := 0; attempt < retries && len(failed) > 0; attempt++ {
+		retried := rerun()
+		if retried.err != nil {
+			fileResult.err = retried.err
+			return runs
+		}
+
+		for _, testResult := range retried.results {
+			i, ok := failed[testResult.TestName]
+			if !ok {
+				continue
+			}
+
+			if testResult.Error != nil {
+				runs[testResult.TestName].fail(seed)
+				continue
+			}
+
+			runs[testResult.TestName].Passed++
+			fileResult.results[i] = testResult
+			if duration, ok := retried.testDurations[testResult.TestName]; ok {
+				fileResult.testDurations[testResult.TestName] = duration
+			}
+			delete(failed, testResult.TestName)
+		}
+	}
+
+	return runs
+}
+
+// unstableTests returns the flaky tests, and when the tests ran more than once, the tests which always failed.
+func (r *result) unstableTests() []unstableTest {
+	tests := make([]unstableTest, 0)
+
+	for file, runs := range r.TestRuns { // nolint:maprange
+		for name, run := range runs { // nolint:maprange
+			if run.flaky() || (run.Failed > 0 && len(r.Seeds) > 1) {
+				tests = append(tests, unstableTest{File: file, Name: name, Runs: run})
+			}
+		}
+	}
+
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].File != tests[j].File {
+			return tests[i].File < tests[j].File
+		}
+		return tests[i].Name < tests[j].Name
+	})
+
+	return tests
+}
+
+// formatUnstableTests renders the tests as a table with the seeds reproducing their failures.
+func formatUnstableTests(tests []unstableTest) string {
+	var b bytes.Buffer
+	writer := util.CreateTabWriter(&b)
+
+	_, _ = fmt.Fprintf(writer, "Test File\tTest\tStatus\tPassed\tFailed\tFailing Seeds\n")
+	for _, test := range tests {
+		status := "failed"
+		if test.Runs.flaky() {
+			status = "flaky"
+		}
+
+		_, _ = fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%d\t%d\t%s\n",
+			test.File,
+			test.Name,
+			status,
+			test.Runs.Passed,
+			test.Runs.Failed,
+			formatSeeds(test.Runs.Seeds),
+		)
+	}
+	_ = writer.Flush()
+
+	return b.String()
+}
+
+func formatSeeds(seeds []int64) string {
+	values := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		values = append(values, strconv.FormatInt(seed, 10))
+	}
+
+	return strings.Join(values, ", ")
+}
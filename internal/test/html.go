@@ -0,0 +1,246 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/common"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/config"
+)
+
+type htmlCoverageLine struct {
+	Number int
+	Code   string
+	// Class is "hit" or "miss" for statements, empty for lines without statements
+	Class string
+	Hits  int
+}
+
+type htmlCoverageFile struct {
+	Name       string
+	Path       string
+	Percentage string
+	Statements int
+	Covered    int
+	// Page is the path of the file's page relative to the index
+	Page string
+	// Index is the path of the index relative to the file's page
+	Index string
+	Lines []htmlCoverageLine
+}
+
+type htmlCoverageReport struct {
+	Percentage string
+	Statements int
+	Covered    int
+	Files      []htmlCoverageFile
+}
+
+const coverageStyle = `<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+table.summary { border-collapse: collapse; margin-bottom: 2em; }
+table.summary th, table.summary td { padding: 4px 12px; border-bottom: 1px solid #ddd; text-align: left; }
+table.source { border-collapse: collapse; font-family: Menlo, Consolas, monospace; font-size: 13px; width: 100%; }
+table.source td { padding: 0 8px; white-space: pre; }
+td.number, td.hits { color: #999; text-align: right; width: 1%; }
+tr.hit td.code { background: #d7f5dd; }
+tr.miss td.code { background: #fbd9d9; }
+</style>`
+
+var coverageIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cadence Coverage Report</title>
+` + coverageStyle + `
+</head>
+<body>
+<h1>Coverage: {{.Percentage}}</h1>
+<p>{{.Covered}} of {{.Statements}} statements covered.</p>
+<table class="summary">
+<tr><th>File</th><th>Coverage</th><th>Covered</th><th>Statements</th></tr>
+{{range .Files}}<tr><td><a href="{{.Page}}">{{.Name}}</a></td><td>{{.Percentage}}</td><td>{{.Covered}}</td><td>{{.Statements}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+var coverageFileTemplate = template.Must(template.New("file").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} - Cadence Coverage Report</title>
+` + coverageStyle + `
+</head>
+<body>
+<p><a href="{{.Index}}">All files</a></p>
+<h1>{{.Name}} <small>{{.Path}} &middot; {{.Percentage}}</small></h1>
+<p>{{.Covered}} of {{.Statements}} statements covered.</p>
+<table class="source">
+{{range .Lines}}<tr class="{{.Class}}"><td class="number">{{.Number}}</td><td class="hits">{{if .Class}}{{.Hits}}{{end}}</td><td class="code">{{.Code}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// coverageHTML renders the coverage report as an index page with a summary of all files at the filename,
+// linking to a page for each file with covered and missed statements highlighted.
+// The pages of the files are placed in a directory named after the index, coverage/Foo.html for coverage.html.
+//
+// Contracts are read from their location in the configuration, if there is no configuration or the
+// source of a location can't be read only its statements are listed.
+//
+// The pages are returned by their path.
+func coverageHTML(
+	report *runtime.CoverageReport,
+	filename string,
+	rw flowkit.ReaderWriter,
+	contracts *config.Contracts,
+) (map[string][]byte, error) {
+	index := htmlCoverageReport{
+		Percentage: report.Percentage(),
+		Statements: report.Statements(),
+		Covered:    report.Hits(),
+		Files:      make([]htmlCoverageFile, 0, len(report.Coverage)),
+	}
+
+	for location, coverage := range report.Coverage { // nolint:maprange
+		name, sourcePath := coverageSource(location, contracts)
+
+		file := htmlCoverageFile{
+			Name:       name,
+			Path:       sourcePath,
+			Percentage: coverage.Percentage(),
+			Statements: coverage.Statements,
+			Covered:    coverage.CoveredLines(),
+		}
+
+		var source []string
+		if code, err := rw.ReadFile(sourcePath); err == nil {
+			source = strings.Split(strings.TrimSuffix(string(code), "\n"), "\n")
+		}
+		file.Lines = coverageLines(source, coverage)
+
+		index.Files = append(index.Files, file)
+	}
+
+	sort.Slice(index.Files, func(i, j int) bool {
+		return index.Files[i].Name < index.Files[j].Name
+	})
+
+	dir := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	pages := make(map[string][]byte, len(index.Files)+1)
+	used := make(map[string]bool, len(index.Files))
+	for i := range index.Files {
+		file := &index.Files[i]
+		file.Page = path.Join(dir, coveragePageName(file.Name, used))
+		file.Index = path.Join("..", filepath.Base(filename))
+
+		var b bytes.Buffer
+		if err := coverageFileTemplate.Execute(&b, file); err != nil {
+			return nil, err
+		}
+		pages[filepath.Join(filepath.Dir(filename), filepath.FromSlash(file.Page))] = b.Bytes()
+	}
+
+	var b bytes.Buffer
+	if err := coverageIndexTemplate.Execute(&b, index); err != nil {
+		return nil, err
+	}
+	pages[filename] = b.Bytes()
+
+	return pages, nil
+}
+
+// coveragePageName returns an unused filename for the page of a file, names of scripts and transactions
+// are paths so their separators are replaced.
+func coveragePageName(name string, used map[string]bool) string {
+	name = strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(name)
+
+	page := name + ".html"
+	for i := 2; used[page]; i++ {
+		page = fmt.Sprintf("%s-%d.html", name, i)
+	}
+	used[page] = true
+
+	return page
+}
+
+// coverageSource returns the name of the location and the path of its source.
+func coverageSource(location common.Location, contracts *config.Contracts) (string, string) {
+	switch location := location.(type) {
+	case common.AddressLocation:
+		if contracts == nil {
+			return location.Name, location.ID()
+		}
+		if contract, err := contracts.ByName(location.Name); err == nil {
+			return location.Name, contract.Location
+		}
+		return location.Name, location.ID()
+	case common.StringLocation:
+		return location.String(), location.String()
+	default:
+		return location.ID(), location.ID()
+	}
+}
+
+// coverageLines annotates the source lines with their hits, without source only the statements are listed.
+func coverageLines(source []string, coverage *runtime.LocationCoverage) []htmlCoverageLine {
+	line := func(number int, code string) htmlCoverageLine {
+		hits, statement := coverage.LineHits[number]
+		l := htmlCoverageLine{Number: number, Code: code, Hits: hits}
+		if statement {
+			l.Class = "miss"
+			if hits > 0 {
+				l.Class = "hit"
+			}
+		}
+		return l
+	}
+
+	lines := make([]htmlCoverageLine, 0, len(source))
+	if len(source) > 0 {
+		for i, code := range source {
+			lines = append(lines, line(i+1, code))
+		}
+		return lines
+	}
+
+	numbers := make([]int, 0, len(coverage.LineHits))
+	for number := range coverage.LineHits { // nolint:maprange
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	for _, number := range numbers {
+		lines = append(lines, line(number, "source not available"))
+	}
+
+	return lines
+}
@@ -0,0 +1,241 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/spf13/cobra"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/config"
+	"github.com/onflow/flowkit/output"
+
+	"github.com/onflow/flow-cli/internal/command"
+)
+
+type flagsMerge struct {
+	CoverProfile string   `default:"coverage.json" flag:"coverprofile" info:"Filename to write the merged coverage report. Supported extensions are .json, .lcov and .html"`
+	CoverMin     float64  `default:"0" flag:"cover-min" info:"Fail if the coverage of any contract or the total coverage is below this percentage"`
+	CoverMins    []string `default:"" flag:"cover-min-contract" info:"Minimum coverage of a contract in the format Contract=percentage, overrides --cover-min and the coverageThresholds of flow.json for the contract, can be repeated"`
+}
+
+var mergeFlags = flagsMerge{}
+
+// CoverageCommand is a top-level command, as a subcommand of the test command it would
+// shadow a test directory named coverage.
+var CoverageCommand = &cobra.Command{
+	Use:     "coverage",
+	Short:   "Manage coverage reports of Cadence tests",
+	GroupID: "tools",
+}
+
+var mergeCommand = &command.Command{
+	Cmd: &cobra.Command{
+		Use:     "merge <coverage.json>...",
+		Short:   "Merge coverage reports written by separate test runs",
+		Example: "flow coverage merge a.json b.json --coverprofile total.json\nflow coverage merge a.json b.json --coverprofile total.lcov",
+		Args:    cobra.MinimumNArgs(1),
+	},
+	Flags:  &mergeFlags,
+	Run:    merge,
+	Status: &status,
+}
+
+func init() {
+	mergeCommand.AddToParent(CoverageCommand)
+}
+
+func merge(
+	args []string,
+	globalFlags command.GlobalFlags,
+	_ output.Logger,
+	readerWriter flowkit.ReaderWriter,
+	_ flowkit.Services,
+) (command.Result, error) {
+	flagThresholds, err := parseCoverageThresholds(mergeFlags.CoverMins)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := loadTestConfig(readerWriter, globalFlags.ConfigPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	// contracts map the source paths in the reports back to contracts, without a configuration
+	// the contract name is taken from the filename
+	contracts := &config.Contracts{}
+	if state, err := flowkit.Load(globalFlags.ConfigPaths, readerWriter); err == nil {
+		contracts = state.Contracts()
+	}
+
+	report, err := mergeCoverageFiles(readerWriter, args, contracts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeCoverage(report, mergeFlags.CoverProfile, readerWriter, contracts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &mergeResult{
+		Files:          args,
+		CoverProfile:   mergeFlags.CoverProfile,
+		CoverageReport: report,
+		Shortfalls:     checkCoverage(report, mergeFlags.CoverMin, conf.coverageThresholds(flagThresholds)),
+	}
+	if len(result.Shortfalls) > 0 {
+		setStatus(1)
+	}
+
+	return result, nil
+}
+
+// coverageFile is the JSON format of runtime.CoverageReport.
+//
+// Contracts of the configuration are keyed by their source path instead of their location ID,
+// which runtime.CoverageReport can't read back, so reports are decoded here.
+type coverageFile struct {
+	Coverage map[string]struct {
+		LineHits   map[int]int `json:"line_hits"`
+		Statements int         `json:"statements"`
+	} `json:"coverage"`
+	ExcludedLocations []string `json:"excluded_locations"`
+}
+
+// mergeCoverageFiles reads the JSON coverage reports and sums the line hits of each location.
+//
+// Contracts found by their source path which are missing from the contracts are added to them,
+// so they can be rendered in HTML reports.
+func mergeCoverageFiles(
+	rw flowkit.ReaderWriter,
+	filenames []string,
+	contracts *config.Contracts,
+) (*runtime.CoverageReport, error) {
+	merged := runtime.NewCoverageReport()
+	mappings := make(map[string]string)
+
+	for _, filename := range filenames {
+		data, err := rw.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("error loading coverage report file: %w", err)
+		}
+
+		var file coverageFile
+		if err := json.Unmarshal(data, &file); err != nil || file.Coverage == nil {
+			return nil, fmt.Errorf("error parsing coverage report %s, only JSON reports can be merged", filename)
+		}
+
+		report := runtime.NewCoverageReport()
+		for key, coverage := range file.Coverage { // nolint:maprange
+			location, _, err := common.DecodeTypeID(nil, key)
+			if err != nil || location == nil {
+				name := coverageContractName(key, contracts)
+				location = common.AddressLocation{Name: name}
+				mappings[name] = key
+			}
+
+			lineHits := coverage.LineHits
+			if lineHits == nil {
+				lineHits = make(map[int]int)
+			}
+			report.Coverage[location] = &runtime.LocationCoverage{
+				LineHits:   lineHits,
+				Statements: coverage.Statements,
+			}
+			report.Locations[location] = struct{}{}
+		}
+		for _, key := range file.ExcludedLocations {
+			location, _, err := common.DecodeTypeID(nil, key)
+			if err == nil && location != nil {
+				report.ExcludedLocations[location] = struct{}{}
+			}
+		}
+
+		mergeCoverage(merged, report)
+	}
+
+	merged.WithLocationMappings(mappings)
+
+	return merged, nil
+}
+
+// coverageContractName returns the name of the contract with the source path, or the filename if there is none.
+func coverageContractName(path string, contracts *config.Contracts) string {
+	for _, contract := range *contracts {
+		if filepath.Clean(contract.Location) == filepath.Clean(path) {
+			return contract.Name
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if _, err := contracts.ByName(name); err != nil {
+		contracts.AddOrUpdate(config.Contract{Name: name, Location: path})
+	}
+
+	return name
+}
+
+type mergeResult struct {
+	Files          []string
+	CoverProfile   string
+	CoverageReport *runtime.CoverageReport
+	Shortfalls     []coverageShortfall
+}
+
+var _ command.Result = &mergeResult{}
+
+func (r *mergeResult) JSON() any {
+	return map[string]any{
+		"files":        r.Files,
+		"coverprofile": r.CoverProfile,
+		"coverage":     r.CoverageReport.Percentage(),
+		"shortfalls":   r.Shortfalls,
+	}
+}
+
+func (r *mergeResult) String() string {
+	var b bytes.Buffer
+
+	_, _ = fmt.Fprintf(
+		&b,
+		"Merged %d coverage reports into %s\nCoverage: %s of statements\n",
+		len(r.Files),
+		r.CoverProfile,
+		r.CoverageReport.Percentage(),
+	)
+
+	if len(r.Shortfalls) > 0 {
+		_, _ = fmt.Fprintf(&b, "\nCoverage is below the minimum:\n%s", formatShortfalls(r.Shortfalls))
+	}
+
+	return b.String()
+}
+
+func (r *mergeResult) Oneliner() string {
+	return fmt.Sprintf("Coverage: %s of statements", r.CoverageReport.Percentage())
+}
@@ -0,0 +1,242 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reporters writing the test results to a file for CI systems.
+const (
+	junitReporter = "junit"
+	tapReporter   = "tap"
+	jsonReporter  = "json"
+)
+
+// Default report file of each reporter when no output path is provided.
+var defaultReportFiles = map[string]string{
+	junitReporter: "test-report.xml",
+	tapReporter:   "test-report.tap",
+	jsonReporter:  "test-report.json",
+}
+
+// report serializes the results in the format of the reporter.
+func report(r *result, reporter string) ([]byte, error) {
+	switch reporter {
+	case junitReporter:
+		return junitReport(r)
+	case tapReporter:
+		return tapReport(r), nil
+	case jsonReporter:
+		return json.MarshalIndent(jsonReport(r), "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported reporter %s, valid reporters are: %s, %s, %s", reporter, junitReporter, tapReporter, jsonReporter)
+	}
+}
+
+// sortedFiles returns the test files of the result in a deterministic order.
+func (r *result) sortedFiles() []string {
+	files := make([]string, 0, len(r.Results))
+	for file := range r.Results {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	return files
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	File      string          `xml:"file,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Time      string        `xml:"time,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// junitReport reports each test file as a test suite, the test cases are only timed when the tests ran one at a time.
+func junitReport(r *result) ([]byte, error) {
+	suites := junitTestSuites{}
+	var total float64
+
+	for _, file := range r.sortedFiles() {
+		suite := junitTestSuite{
+			Name: file,
+			File: file,
+			Time: seconds(r.Durations[file].Seconds()),
+		}
+		total += r.Durations[file].Seconds()
+
+		for _, testResult := range r.Results[file] {
+			testCase := junitTestCase{
+				Name:      testResult.TestName,
+				ClassName: strings.TrimSuffix(file, ".cdc"),
+				File:      file,
+			}
+			if duration, ok := r.TestDurations[file][testResult.TestName]; ok {
+				testCase.Time = seconds(duration.Seconds())
+			}
+			if testResult.Error != nil {
+				message := testResult.Error.Error()
+				testCase.Failure = &junitFailure{
+					Message:  strings.SplitN(message, "\n", 2)[0],
+					Contents: message,
+				}
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+			suite.Tests++
+		}
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+	suites.Time = seconds(total)
+
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error serializing JUnit report: %w", err)
+	}
+
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
+
+// tapReport reports the results in TAP version 13, failures include a YAML block with the message and file.
+func tapReport(r *result) []byte {
+	var b bytes.Buffer
+	count := 0
+	for _, testResults := range r.Results {
+		count += len(testResults)
+	}
+
+	b.WriteString("TAP version 13\n")
+	b.WriteString(fmt.Sprintf("1..%d\n", count))
+
+	number := 0
+	for _, file := range r.sortedFiles() {
+		for _, testResult := range r.Results[file] {
+			number++
+			if testResult.Error == nil {
+				b.WriteString(fmt.Sprintf("ok %d - %s: %s\n", number, file, testResult.TestName))
+				continue
+			}
+
+			b.WriteString(fmt.Sprintf("not ok %d - %s: %s\n", number, file, testResult.TestName))
+			b.WriteString("  ---\n")
+			b.WriteString("  message: |\n")
+			for _, line := range strings.Split(testResult.Error.Error(), "\n") {
+				b.WriteString(fmt.Sprintf("    %s\n", line))
+			}
+			b.WriteString(fmt.Sprintf("  file: %q\n", file))
+			b.WriteString("  ...\n")
+		}
+	}
+
+	return b.Bytes()
+}
+
+type jsonTestCase struct {
+	Name         string  `json:"name"`
+	Passed       bool    `json:"passed"`
+	Error        string  `json:"error,omitempty"`
+	Flaky        bool    `json:"flaky,omitempty"`
+	FailingSeeds []int64 `json:"failingSeeds,omitempty"`
+}
+
+type jsonTestFile struct {
+	File     string         `json:"file"`
+	Duration float64        `json:"duration"`
+	Tests    []jsonTestCase `json:"tests"`
+}
+
+type jsonTestReport struct {
+	Files    []jsonTestFile `json:"files"`
+	Coverage string         `json:"coverage,omitempty"`
+	Seed     int64          `json:"seed,omitempty"`
+	Seeds    []int64        `json:"seeds,omitempty"`
+}
+
+// jsonReport is a structured report, unlike the command JSON output it keeps errors and durations separate.
+func jsonReport(r *result) jsonTestReport {
+	report := jsonTestReport{
+		Files: make([]jsonTestFile, 0, len(r.Results)),
+		Seed:  r.RandomSeed,
+	}
+	if len(r.Seeds) > 1 {
+		report.Seeds = r.Seeds
+	}
+	if r.CoverageReport != nil {
+		report.Coverage = r.CoverageReport.Percentage()
+	}
+
+	for _, file := range r.sortedFiles() {
+		testFile := jsonTestFile{
+			File:     file,
+			Duration: r.Durations[file].Seconds(),
+			Tests:    make([]jsonTestCase, 0, len(r.Results[file])),
+		}
+
+		for _, testResult := range r.Results[file] {
+			testCase := jsonTestCase{Name: testResult.TestName, Passed: testResult.Error == nil}
+			if testResult.Error != nil {
+				testCase.Error = testResult.Error.Error()
+			}
+			if runs, ok := r.TestRuns[file][testResult.TestName]; ok && runs.Failed > 0 {
+				testCase.Flaky = runs.flaky()
+				testCase.FailingSeeds = runs.Seeds
+			}
+			testFile.Tests = append(testFile.Tests, testCase)
+		}
+
+		report.Files = append(report.Files, testFile)
+	}
+
+	return report
+}
@@ -20,12 +20,15 @@ package test
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	cdcTests "github.com/onflow/cadence-tools/test"
 	"github.com/onflow/cadence/runtime"
@@ -52,6 +55,10 @@ const TestReportCoverageKey = "coverage"
 // in JSON format can be found.
 const TestReportSeedKey = "seed"
 
+// The key where the flaky tests of a test report in JSON
+// format can be found.
+const TestReportFlakyKey = "flaky"
+
 // Import statements with a path that contain this substring,
 // are considered to be helper/utility scripts for test files.
 const helperScriptSubstr = "_helper"
@@ -61,24 +68,36 @@ const helperScriptSubstr = "_helper"
 const contractsCoverCode = "contracts"
 
 type flagsTests struct {
-	Cover        bool   `default:"false" flag:"cover" info:"Use the cover flag to calculate coverage report"`
-	CoverProfile string `default:"coverage.json" flag:"coverprofile" info:"Filename to write the calculated coverage report. Supported extensions are .json and .lcov"`
-	CoverCode    string `default:"all" flag:"covercode" info:"Use the covercode flag to calculate coverage report only for certain types of code. Available values are \"all\" & \"contracts\""`
-	Random       bool   `default:"false" flag:"random" info:"Use the random flag to execute test cases randomly"`
-	Seed         int64  `default:"0" flag:"seed" info:"Use the seed flag to manipulate random execution of test cases"`
-	Name         string `default:"" flag:"name" info:"Use the name flag to run only tests that match the given name"`
+	Cover        bool     `default:"false" flag:"cover" info:"Use the cover flag to calculate coverage report"`
+	CoverProfile string   `default:"coverage.json" flag:"coverprofile" info:"Filename to write the calculated coverage report. Supported extensions are .json, .lcov and .html"`
+	CoverCode    string   `default:"all" flag:"covercode" info:"Use the covercode flag to calculate coverage report only for certain types of code. Available values are \"all\" & \"contracts\""`
+	Random       bool     `default:"false" flag:"random" info:"Use the random flag to execute test cases randomly"`
+	Seed         int64    `default:"0" flag:"seed" info:"Use the seed flag to manipulate random execution of test cases"`
+	Name         string   `default:"" flag:"name" info:"Use the name flag to run only tests that match the given name, selected tests run in isolation"`
+	Run          string   `default:"" flag:"run" info:"Run only tests whose name matches the regular expression, in all test files. Each selected test runs in isolation, with its own setup and tear down and without the state of other tests"`
+	Skip         string   `default:"" flag:"skip" info:"Skip tests whose name matches the regular expression, in all test files. The other tests then run in isolation, like with --run"`
+	Watch        bool     `default:"false" flag:"watch" info:"Re-run the affected test files whenever a test, helper or imported contract changes"`
+	CoverMin     float64  `default:"0" flag:"cover-min" info:"Fail if the coverage of any contract or the total coverage is below this percentage"`
+	CoverMins    []string `default:"" flag:"cover-min-contract" info:"Minimum coverage of a contract in the format Contract=percentage, overrides --cover-min and the coverageThresholds of flow.json for the contract, can be repeated"`
+	Parallel     int      `default:"1" flag:"parallel" info:"Number of test files to run concurrently"`
+	Count        int      `default:"1" flag:"count" info:"Run the tests this many times in random order, each run with the next seed, to detect flaky tests"`
+	Retries      int      `default:"0" flag:"retries" info:"Re-run test files with failed tests up to this many times in the same order, failed tests passing on a retry are reported as flaky"`
+	Reporter     string   `default:"" flag:"reporter" info:"Write a test report for CI systems. Available values are \"junit\", \"tap\" & \"json\""`
+	ReportFile   string   `default:"" flag:"reporter-output" info:"Filename to write the test report, defaults to test-report with the extension of the reporter"`
 }
 
 var testFlags = flagsTests{}
 
 var status = 0
 
+var statusMutex sync.Mutex
+
 var TestCommand = &command.Command{
 	Cmd: &cobra.Command{
-		Use:     "test <filename>",
+		Use:     "test [<filename | directory | pattern>...]",
 		Short:   "Run Cadence tests",
-		Example: `flow test script.cdc`,
-		Args:    cobra.MinimumNArgs(1),
+		Example: "flow test\nflow test script_test.cdc\nflow test ./cadence/tests/...",
+		Args:    cobra.ArbitraryArgs,
 		GroupID: "tools",
 	},
 	Flags:  &testFlags,
@@ -88,7 +107,7 @@ var TestCommand = &command.Command{
 
 func run(
 	args []string,
-	_ command.GlobalFlags,
+	globalFlags command.GlobalFlags,
 	logger output.Logger,
 	_ flowkit.Services,
 	state *flowkit.State,
@@ -96,6 +115,28 @@ func run(
 	if !testFlags.Cover && testFlags.CoverProfile != "coverage.json" {
 		return nil, fmt.Errorf("the '--coverprofile' flag requires the '--cover' flag")
 	}
+	flagThresholds, err := parseCoverageThresholds(testFlags.CoverMins)
+	if err != nil {
+		return nil, err
+	}
+	if !testFlags.Cover && (testFlags.CoverMin > 0 || len(flagThresholds) > 0) {
+		return nil, fmt.Errorf("the '--cover-min' and '--cover-min-contract' flags require the '--cover' flag")
+	}
+	if testFlags.Reporter == "" && testFlags.ReportFile != "" {
+		return nil, fmt.Errorf("the '--reporter-output' flag requires the '--reporter' flag")
+	}
+	if _, ok := defaultReportFiles[testFlags.Reporter]; testFlags.Reporter != "" && !ok {
+		return nil, fmt.Errorf("given reporter: %s, only junit, tap and json are supported", testFlags.Reporter)
+	}
+	if testFlags.Parallel < 1 {
+		return nil, fmt.Errorf("the '--parallel' flag must be at least 1")
+	}
+	if testFlags.Count < 1 {
+		return nil, fmt.Errorf("the '--count' flag must be at least 1")
+	}
+	if testFlags.Retries < 0 {
+		return nil, fmt.Errorf("the '--retries' flag can't be negative")
+	}
 	if testFlags.Random && testFlags.Seed > 0 {
 		fmt.Printf(
 			"%s Both '--seed' and '--random' flags are used. Hence, the '--random' flag will be ignored.\n",
@@ -103,41 +144,56 @@ func run(
 		)
 	}
 
-	testFiles := make(map[string][]byte, 0)
-	for _, filename := range args {
-		code, err := state.ReadFile(filename)
-		if err != nil {
-			return nil, fmt.Errorf("error loading script file: %w", err)
-		}
+	if testFlags.Watch {
+		return nil, watch(args, globalFlags, logger, state, testFlags)
+	}
+
+	conf, err := loadTestConfig(state.ReaderWriter(), globalFlags.ConfigPaths)
+	if err != nil {
+		return nil, err
+	}
 
-		testFiles[filename] = code
+	filenames, err := discoverTestFiles(state.ReaderWriter(), globalFlags.ConfigPaths, args)
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := testCode(testFiles, state, testFlags)
+	testFiles, err := loadTestFiles(state, filenames)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := testRepeatedly(testFiles, state, testFlags)
 	if err != nil {
 		return nil, err
 	}
 
 	if result.CoverageReport != nil {
-		var file []byte
-		var err error
-
-		ext := filepath.Ext(testFlags.CoverProfile)
-		if ext == ".json" {
-			file, err = json.MarshalIndent(result.CoverageReport, "", "  ")
-		} else if ext == ".lcov" {
-			file, err = result.CoverageReport.MarshalLCOV()
-		} else {
-			return nil, fmt.Errorf("given format: %v, only .json and .lcov are supported", ext)
+		err = writeCoverage(result.CoverageReport, testFlags.CoverProfile, state.ReaderWriter(), state.Contracts())
+		if err != nil {
+			return nil, err
+		}
+
+		result.CoverageShortfalls = checkCoverage(result.CoverageReport, testFlags.CoverMin, conf.coverageThresholds(flagThresholds))
+		if len(result.CoverageShortfalls) > 0 {
+			setStatus(1)
 		}
+	}
 
+	if testFlags.Reporter != "" {
+		file, err := report(result, testFlags.Reporter)
 		if err != nil {
-			return nil, fmt.Errorf("error serializing coverage report: %w", err)
+			return nil, err
 		}
 
-		err = os.WriteFile(testFlags.CoverProfile, file, 0644)
+		reportFile := testFlags.ReportFile
+		if reportFile == "" {
+			reportFile = defaultReportFiles[testFlags.Reporter]
+		}
+
+		err = os.WriteFile(reportFile, file, 0644)
 		if err != nil {
-			return nil, fmt.Errorf("error writing coverage report file: %w", err)
+			return nil, fmt.Errorf("error writing test report file: %w", err)
 		}
 	}
 
@@ -149,32 +205,16 @@ func testCode(
 	state *flowkit.State,
 	flags flagsTests,
 ) (*result, error) {
-	runner := cdcTests.NewTestRunner()
-
 	var coverageReport *runtime.CoverageReport
 	if flags.Cover {
-		coverageReport = state.CreateCoverageReport("testing")
-		if flags.CoverCode == contractsCoverCode {
-			coverageReport.WithLocationFilter(
-				func(location common.Location) bool {
-					_, addressLoc := location.(common.AddressLocation)
-					// We only allow inspection of AddressLocation,
-					// since scripts and transactions cannot be
-					// attributed to their source files anyway.
-					return addressLoc
-				},
-			)
-		}
-		runner = runner.WithCoverageReport(coverageReport)
+		coverageReport = newCoverageReport(state, flags)
 	}
 
 	var seed int64
 	if flags.Seed > 0 {
 		seed = flags.Seed
-		runner = runner.WithRandomSeed(seed)
 	} else if flags.Random {
 		seed = int64(rand.Intn(150000))
-		runner = runner.WithRandomSeed(seed)
 	}
 
 	contractsConfig := *state.Contracts()
@@ -186,54 +226,306 @@ func testCode(
 		}
 	}
 
-	testResults := make(map[string]cdcTests.Results, 0)
-	for scriptPath, code := range testFiles {
-		runner := runner.
-			WithImportResolver(importResolver(scriptPath, state)).
-			WithFileResolver(fileResolver(scriptPath, state)).
-			WithContracts(contracts)
-
-		if flags.Name != "" {
-			testFunctions, err := runner.GetTests(string(code))
-			if err != nil {
-				return nil, err
+	deps, err := newTestDependencies(state, contracts)
+	if err != nil {
+		return nil, err
+	}
+	for name, address := range deps.aliases { // nolint:maprange
+		contracts[name] = address
+	}
+
+	filter, err := newTestFilter(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	scriptPaths := make([]string, 0, len(testFiles))
+	for scriptPath := range testFiles {
+		scriptPaths = append(scriptPaths, scriptPath)
+	}
+	sort.Strings(scriptPaths)
+
+	parallel := flags.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	// Each file runs with its own runner and coverage report, so files are independent
+	// and can run concurrently. Results are collected by index to keep the output ordered.
+	fileResults := make([]fileResult, len(scriptPaths))
+	semaphore := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i, scriptPath := range scriptPaths {
+		wg.Add(1)
+		go func(i int, scriptPath string) {
+			semaphore <- struct{}{}
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			files := newFileSet(scriptPath)
+			runner := cdcTests.NewTestRunner().
+				WithImportResolver(importResolver(scriptPath, state, deps, files)).
+				WithFileResolver(fileResolver(scriptPath, state, deps, files)).
+				WithContracts(contracts)
+			if seed > 0 {
+				runner = runner.WithRandomSeed(seed)
 			}
 
-			for _, testFunction := range testFunctions {
-				if testFunction != flags.Name {
-					continue
-				}
+			var fileCoverage *runtime.CoverageReport
+			if flags.Cover {
+				fileCoverage = newCoverageReport(state, flags)
+				runner = runner.WithCoverageReport(fileCoverage)
+			}
 
-				result, err := runner.RunTest(string(code), flags.Name)
-				if err != nil {
-					return nil, err
+			fileResults[i] = runTestFile(runner, testFiles[scriptPath], filter, seed)
+			if fileResults[i].err == nil {
+				rerun := func() fileResult {
+					return runTestFile(runner, testFiles[scriptPath], filter, seed)
 				}
-				testResults[scriptPath] = []cdcTests.Result{*result}
-			}
-		} else {
-			results, err := runner.RunTests(string(code))
-			if err != nil {
-				return nil, err
+				fileResults[i].runs = retryFailedTests(rerun, &fileResults[i], flags.Retries, seed)
 			}
-			testResults[scriptPath] = results
+			fileResults[i].coverage = fileCoverage
+			fileResults[i].files = files
+		}(i, scriptPath)
+	}
+	wg.Wait()
+
+	testResults := make(map[string]cdcTests.Results, 0)
+	durations := make(map[string]time.Duration, len(testFiles))
+	testDurations := make(map[string]map[string]time.Duration, len(testFiles))
+	dependencies := make(map[string]*fileSet, len(testFiles))
+	runs := make(map[string]map[string]*testRuns, len(testFiles))
+	for i, scriptPath := range scriptPaths {
+		fileResult := fileResults[i]
+		if fileResult.err != nil {
+			return nil, fileResult.err
+		}
+		dependencies[scriptPath] = fileResult.files
+
+		if fileResult.coverage != nil {
+			mergeCoverage(coverageReport, fileResult.coverage)
 		}
 
-		for _, result := range testResults[scriptPath] {
+		if fileResult.results == nil {
+			continue
+		}
+		testResults[scriptPath] = fileResult.results
+		durations[scriptPath] = fileResult.duration
+		if fileResult.testDurations != nil {
+			testDurations[scriptPath] = fileResult.testDurations
+		}
+		runs[scriptPath] = fileResult.runs
+
+		for _, result := range fileResult.results {
 			if result.Error != nil {
-				status = 1
+				setStatus(1)
 				break
 			}
 		}
 	}
 
+	if filter != nil && len(testResults) == 0 {
+		return nil, fmt.Errorf("no tests matched %s in %d test files", filter, len(testFiles))
+	}
+
 	return &result{
 		Results:        testResults,
+		Durations:      durations,
+		TestDurations:  testDurations,
 		CoverageReport: coverageReport,
 		RandomSeed:     seed,
+		TestRuns:       runs,
+		dependencies:   dependencies,
 	}, nil
 }
 
-func importResolver(scriptPath string, state *flowkit.State) cdcTests.ImportResolver {
+// fileResult is the outcome of running a single test file.
+type fileResult struct {
+	results  cdcTests.Results
+	duration time.Duration
+	// testDurations are the durations by test name, only measured if the tests ran one at a time
+	testDurations map[string]time.Duration
+	coverage      *runtime.CoverageReport
+	files         *fileSet
+	runs          map[string]*testRuns
+	err           error
+}
+
+// runTestFile runs all the tests of the file, or only the tests selected by the filter.
+//
+// Selected tests run one at a time, each with its own setup and tear down, so the duration of each test is measured.
+func runTestFile(runner *cdcTests.TestRunner, code []byte, filter *testFilter, seed int64) fileResult {
+	start := time.Now()
+
+	if filter == nil {
+		results, err := runner.RunTests(string(code))
+		return fileResult{results: results, duration: time.Since(start), err: err}
+	}
+
+	testFunctions, err := runner.GetTests(string(code))
+	if err != nil {
+		return fileResult{err: err}
+	}
+
+	selected := make([]string, 0)
+	for _, testFunction := range testFunctions {
+		if filter == nil || filter.matches(testFunction) {
+			selected = append(selected, testFunction)
+		}
+	}
+	if len(selected) == 0 {
+		return fileResult{}
+	}
+
+	if seed > 0 {
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(selected), func(i, j int) {
+			selected[i], selected[j] = selected[j], selected[i]
+		})
+	}
+
+	results := make(cdcTests.Results, 0, len(selected))
+	testDurations := make(map[string]time.Duration, len(selected))
+	for _, testFunction := range selected {
+		testStart := time.Now()
+		result, err := runner.RunTest(string(code), testFunction)
+		if err != nil {
+			return fileResult{err: err}
+		}
+		testDurations[testFunction] = time.Since(testStart)
+		results = append(results, *result)
+	}
+
+	return fileResult{results: results, duration: time.Since(start), testDurations: testDurations}
+}
+
+// testFilter selects tests by exact name and by the run and skip regular expressions.
+type testFilter struct {
+	name string
+	run  *regexp.Regexp
+	skip *regexp.Regexp
+}
+
+// newTestFilter returns nil if the flags don't filter any tests.
+func newTestFilter(flags flagsTests) (*testFilter, error) {
+	if flags.Name == "" && flags.Run == "" && flags.Skip == "" {
+		return nil, nil
+	}
+
+	filter := &testFilter{name: flags.Name}
+
+	var err error
+	if flags.Run != "" {
+		filter.run, err = regexp.Compile(flags.Run)
+		if err != nil {
+			return nil, fmt.Errorf("invalid '--run' regular expression: %w", err)
+		}
+	}
+	if flags.Skip != "" {
+		filter.skip, err = regexp.Compile(flags.Skip)
+		if err != nil {
+			return nil, fmt.Errorf("invalid '--skip' regular expression: %w", err)
+		}
+	}
+
+	return filter, nil
+}
+
+func (f *testFilter) matches(testName string) bool {
+	if f.name != "" && testName != f.name {
+		return false
+	}
+	if f.run != nil && !f.run.MatchString(testName) {
+		return false
+	}
+	if f.skip != nil && f.skip.MatchString(testName) {
+		return false
+	}
+
+	return true
+}
+
+func (f *testFilter) String() string {
+	conditions := make([]string, 0)
+	if f.name != "" {
+		conditions = append(conditions, fmt.Sprintf("--name %s", f.name))
+	}
+	if f.run != nil {
+		conditions = append(conditions, fmt.Sprintf("--run %s", f.run))
+	}
+	if f.skip != nil {
+		conditions = append(conditions, fmt.Sprintf("--skip %s", f.skip))
+	}
+
+	return strings.Join(conditions, " ")
+}
+
+func newCoverageReport(state *flowkit.State, flags flagsTests) *runtime.CoverageReport {
+	coverageReport := state.CreateCoverageReport("testing")
+	if flags.CoverCode == contractsCoverCode {
+		coverageReport.WithLocationFilter(
+			func(location common.Location) bool {
+				_, addressLoc := location.(common.AddressLocation)
+				// We only allow inspection of AddressLocation,
+				// since scripts and transactions cannot be
+				// attributed to their source files anyway.
+				return addressLoc
+			},
+		)
+	}
+
+	return coverageReport
+}
+
+// mergeCoverage adds the coverage of other to the report, line hits of locations covered by both are summed.
+//
+// Unlike runtime.CoverageReport.Merge, which replaces the coverage of a location, this keeps the hits
+// of every test file that used the same contract.
+func mergeCoverage(report *runtime.CoverageReport, other *runtime.CoverageReport) {
+	for location, coverage := range other.Coverage { // nolint:maprange
+		existing, ok := report.Coverage[location]
+		if !ok {
+			existing = runtime.NewLocationCoverage(make(map[int]int, len(coverage.LineHits)))
+			report.Coverage[location] = existing
+		}
+
+		for line, hits := range coverage.LineHits { // nolint:maprange
+			existing.LineHits[line] += hits
+		}
+		if coverage.Statements > existing.Statements {
+			existing.Statements = coverage.Statements
+		}
+		if len(existing.LineHits) > existing.Statements {
+			existing.Statements = len(existing.LineHits)
+		}
+	}
+	for location, v := range other.Locations { // nolint:maprange
+		report.Locations[location] = v
+	}
+	for location, v := range other.ExcludedLocations { // nolint:maprange
+		report.ExcludedLocations[location] = v
+	}
+}
+
+// setStatus sets the exit status of the command, it is safe to call from multiple goroutines.
+func setStatus(code int) {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+
+	status = code
+}
+
+// importResolver resolves contracts by name from the configuration, or from the installed dependencies if
+// they are not part of it, and helper scripts relative to the test file.
+func importResolver(
+	scriptPath string,
+	state *flowkit.State,
+	deps *testDependencies,
+	files *fileSet,
+) cdcTests.ImportResolver {
 	contracts := make(map[string]config.Contract, 0)
 	for _, contract := range *state.Contracts() {
 		contracts[contract.Name] = contract
@@ -251,6 +543,7 @@ func importResolver(scriptPath string, state *flowkit.State) cdcTests.ImportReso
 
 			if strings.Contains(relativePath, helperScriptSubstr) {
 				importedScriptFilePath := absolutePath(scriptPath, relativePath)
+				files.add(importedScriptFilePath)
 				scriptCode, err := state.ReadFile(importedScriptFilePath)
 				if err != nil {
 					return "", nil
@@ -261,6 +554,12 @@ func importResolver(scriptPath string, state *flowkit.State) cdcTests.ImportReso
 			contract = contracts[relativePath]
 		}
 
+		if contract.Location == "" {
+			if location, ok := deps.location(contractName(location)); ok {
+				contract.Location = location
+			}
+		}
+
 		if contract.Location == "" {
 			return "", fmt.Errorf(
 				"cannot find contract with location '%s' in configuration",
@@ -268,25 +567,36 @@ func importResolver(scriptPath string, state *flowkit.State) cdcTests.ImportReso
 			)
 		}
 
+		files.add(contract.Location)
 		contractCode, err := state.ReadFile(contract.Location)
 		if err != nil {
 			return "", err
 		}
 
-		return string(contractCode), nil
+		return deps.rewriteImports(string(contractCode)), nil
 	}
 }
 
-func fileResolver(scriptPath string, state *flowkit.State) cdcTests.FileResolver {
+// contractName returns the name of the contract imported from the location.
+func contractName(location common.Location) string {
+	if location, ok := location.(common.AddressLocation); ok {
+		return location.Name
+	}
+
+	return location.String()
+}
+
+func fileResolver(scriptPath string, state *flowkit.State, deps *testDependencies, files *fileSet) cdcTests.FileResolver {
 	return func(path string) (string, error) {
 		importFilePath := absolutePath(scriptPath, path)
+		files.add(importFilePath)
 
 		content, err := state.ReadFile(importFilePath)
 		if err != nil {
 			return "", err
 		}
 
-		return string(content), nil
+		return deps.rewriteImports(string(content)), nil
 	}
 }
 
@@ -300,8 +610,19 @@ func absolutePath(basePath, filePath string) string {
 
 type result struct {
 	Results        map[string]cdcTests.Results
+	Durations      map[string]time.Duration
 	CoverageReport *runtime.CoverageReport
 	RandomSeed     int64
+	// TestDurations are the durations by test file and test name, only measured if the tests ran one at a time
+	TestDurations map[string]map[string]time.Duration
+	// CoverageShortfalls are the contracts below the minimum coverage
+	CoverageShortfalls []coverageShortfall
+	// Seeds are the seeds of each run when the tests ran more than once
+	Seeds []int64
+	// TestRuns are the outcomes of each test over all runs and retries, by test file and test name
+	TestRuns map[string]map[string]*testRuns
+	// dependencies are the files read by each test file, used to find the tests affected by a change
+	dependencies map[string]*fileSet
 }
 
 var _ command.Result = &result{}
@@ -327,9 +648,20 @@ func (r *result) JSON() any {
 	if r.CoverageReport != nil {
 		meta[TestReportCoverageKey] = r.CoverageReport.Percentage()
 	}
-	if r.RandomSeed > 0 {
+	if len(r.Seeds) > 1 {
+		meta[TestReportSeedKey] = formatSeeds(r.Seeds)
+	} else if r.RandomSeed > 0 {
 		meta[TestReportSeedKey] = fmt.Sprint(r.RandomSeed)
 	}
+	flaky := make([]string, 0)
+	for _, test := range r.unstableTests() {
+		if test.Runs.flaky() {
+			flaky = append(flaky, fmt.Sprintf("%s:%s", test.File, test.Name))
+		}
+	}
+	if len(flaky) > 0 {
+		meta[TestReportFlakyKey] = strings.Join(flaky, ", ")
+	}
 	results[TestReportMetaKey] = meta
 
 	return results
@@ -339,13 +671,21 @@ func (r *result) String() string {
 	var b bytes.Buffer
 	writer := util.CreateTabWriter(&b)
 
-	for scriptPath, testResult := range r.Results {
-		_, _ = fmt.Fprint(writer, cdcTests.PrettyPrintResults(testResult, scriptPath))
+	for _, scriptPath := range r.sortedFiles() {
+		_, _ = fmt.Fprint(writer, cdcTests.PrettyPrintResults(r.Results[scriptPath], scriptPath))
 	}
 	if r.CoverageReport != nil {
 		_, _ = fmt.Fprint(writer, r.CoverageReport.String())
 	}
-	if r.RandomSeed > 0 {
+	if len(r.CoverageShortfalls) > 0 {
+		_, _ = fmt.Fprintf(writer, "\n\n%s Coverage is below the minimum:\n%s", output.ErrorEmoji(), formatShortfalls(r.CoverageShortfalls))
+	}
+	if unstable := r.unstableTests(); len(unstable) > 0 {
+		_, _ = fmt.Fprintf(writer, "\n\n%s Flaky and failing tests:\n%s", output.WarningEmoji(), formatUnstableTests(unstable))
+	}
+	if len(r.Seeds) > 1 {
+		_, _ = fmt.Fprintf(writer, "\nSeeds: %s", formatSeeds(r.Seeds))
+	} else if r.RandomSeed > 0 {
 		_, _ = fmt.Fprintf(writer, "\nSeed: %d", r.RandomSeed)
 	}
 
@@ -362,14 +702,20 @@ func (r *result) Oneliner() string {
 		return builder.String()
 	}
 
-	for scriptPath, testResult := range r.Results {
-		builder.WriteString(cdcTests.PrettyPrintResults(testResult, scriptPath))
+	for _, scriptPath := range r.sortedFiles() {
+		builder.WriteString(cdcTests.PrettyPrintResults(r.Results[scriptPath], scriptPath))
 	}
 	if r.CoverageReport != nil {
 		builder.WriteString(r.CoverageReport.String())
 		builder.WriteString("\n")
 	}
-	if r.RandomSeed > 0 {
+	if unstable := r.unstableTests(); len(unstable) > 0 {
+		builder.WriteString(formatUnstableTests(unstable))
+	}
+	if len(r.Seeds) > 1 {
+		builder.WriteString(fmt.Sprintf("Seeds: %s", formatSeeds(r.Seeds)))
+		builder.WriteString("\n")
+	} else if r.RandomSeed > 0 {
 		builder.WriteString(fmt.Sprintf("Seed: %d", r.RandomSeed))
 		builder.WriteString("\n")
 	}
@@ -20,10 +20,15 @@ package test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	cdcTests "github.com/onflow/cadence-tools/test"
+	"github.com/onflow/cadence/runtime"
 	"github.com/onflow/cadence/runtime/common"
 	"github.com/onflow/cadence/runtime/stdlib"
 	"github.com/onflow/flow-go-sdk"
@@ -33,9 +38,26 @@ import (
 	"github.com/onflow/flowkit/config"
 	"github.com/onflow/flowkit/tests"
 
+	"github.com/onflow/flow-cli/internal/command"
 	"github.com/onflow/flow-cli/internal/util"
 )
 
+// flakyTestScript has a test which fails when it runs after the other test of the file.
+var flakyTestScript = []byte(`
+	import Test
+
+	access(all) var counter = 0
+
+	access(all) fun testIncrement() {
+		counter = counter + 1
+		Test.assertEqual(1, counter)
+	}
+
+	access(all) fun testZero() {
+		Test.assertEqual(0, counter)
+	}
+`)
+
 func TestExecutingTests(t *testing.T) {
 	t.Parallel()
 
@@ -79,6 +101,28 @@ func TestExecutingTests(t *testing.T) {
 		assert.ErrorAs(t, err, &stdlib.AssertionError{})
 	})
 
+	t.Run("timed when selected", func(t *testing.T) {
+		t.Parallel()
+
+		_, state, _ := util.TestMocks(t)
+
+		script := tests.TestScriptSimple
+		testFiles := map[string][]byte{
+			script.Filename: script.Source,
+		}
+		result, err := testCode(testFiles, state, flagsTests{Run: "."})
+
+		require.NoError(t, err)
+		require.Len(t, result.Results[script.Filename], 1)
+		testName := result.Results[script.Filename][0].TestName
+		assert.Positive(t, result.TestDurations[script.Filename][testName])
+
+		// the reporter doesn't change how the tests run
+		result, err = testCode(testFiles, state, flagsTests{Reporter: junitReporter})
+		require.NoError(t, err)
+		assert.Empty(t, result.TestDurations)
+	})
+
 	t.Run("with import", func(t *testing.T) {
 		t.Parallel()
 
@@ -162,6 +206,210 @@ func TestExecutingTests(t *testing.T) {
 		assert.NoError(t, result.Results[script.Filename][0].Error)
 	})
 
+	t.Run("with dependencies for watch mode", func(t *testing.T) {
+		t.Parallel()
+
+		_, state, _ := util.TestMocks(t)
+
+		state.Contracts().AddOrUpdate(config.Contract{
+			Name:     tests.ContractHelloString.Name,
+			Location: tests.ContractHelloString.Filename,
+			Aliases:  aliases,
+		})
+
+		// Execute scripts
+		testFiles := map[string][]byte{
+			tests.TestScriptWithImport.Filename:       tests.TestScriptWithImport.Source,
+			tests.TestScriptWithHelperImport.Filename: tests.TestScriptWithHelperImport.Source,
+		}
+		result, err := testCode(testFiles, state, flagsTests{})
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			[]string{tests.ContractHelloString.Filename, tests.TestScriptWithImport.Filename},
+			result.dependencies[tests.TestScriptWithImport.Filename].list(),
+		)
+		assert.Equal(t,
+			[]string{tests.TestScriptWithHelperImport.Filename, "test_helpers.cdc"},
+			result.dependencies[tests.TestScriptWithHelperImport.Filename].list(),
+		)
+
+		assert.Equal(t,
+			[]string{tests.TestScriptWithImport.Filename},
+			affectedTests(result.dependencies, []string{"./" + tests.ContractHelloString.Filename}),
+		)
+		assert.Equal(t,
+			[]string{tests.TestScriptWithHelperImport.Filename},
+			affectedTests(result.dependencies, []string{"test_helpers.cdc", "README.md"}),
+		)
+
+		summary := watchSummary(result)
+		assert.Contains(t, summary, "2 passed in 2 files")
+	})
+
+	t.Run("with installed dependencies", func(t *testing.T) {
+		t.Parallel()
+
+		_, state, rw := util.TestMocks(t)
+
+		// Bar is installed by the dependency manager, Baz only exists in the imports directory
+		source := flow.HexToAddress("0x1234567890abcdef")
+		require.NoError(t, rw.WriteFile("imports/1234567890abcdef/Baz.cdc", []byte(`
+			pub contract Baz {
+				pub let value: Int
+				init() {
+					self.value = 42
+				}
+			}
+		`), 0644))
+		require.NoError(t, rw.WriteFile("imports/1234567890abcdef/Bar.cdc", []byte(`
+			import Baz from 0x1234567890abcdef
+
+			pub contract Bar {
+				pub fun value(): Int {
+					return Baz.value
+				}
+			}
+		`), 0644))
+		state.Dependencies().AddOrUpdate(config.Dependency{
+			Name: "Bar",
+			Source: config.Source{
+				NetworkName:  "mainnet",
+				Address:      source,
+				ContractName: "Bar",
+			},
+		})
+		state.Contracts().AddOrUpdate(config.Contract{
+			Name:     "Bar",
+			Location: "imports/1234567890abcdef/Bar.cdc",
+			Aliases:  config.Aliases{{Network: "mainnet", Address: source}},
+		})
+
+		script := []byte(`
+			import Test
+			import "Bar"
+
+			pub fun setup() {
+				var err = Test.deployContract(name: "Baz", path: "imports/1234567890abcdef/Baz.cdc", arguments: [])
+				Test.expect(err, Test.beNil())
+				err = Test.deployContract(name: "Bar", path: "imports/1234567890abcdef/Bar.cdc", arguments: [])
+				Test.expect(err, Test.beNil())
+			}
+
+			pub fun testValue() {
+				Test.assertEqual(42, Bar.value())
+			}
+		`)
+		result, err := testCode(map[string][]byte{"dependencies_test.cdc": script}, state, flagsTests{})
+		require.NoError(t, err)
+		require.Len(t, result.Results["dependencies_test.cdc"], 1)
+		assert.NoError(t, result.Results["dependencies_test.cdc"][0].Error)
+	})
+
+	t.Run("with retries of failed tests", func(t *testing.T) {
+		t.Parallel()
+
+		_, state, _ := util.TestMocks(t)
+
+		// testZero only passes when it runs before testIncrement, or on its own
+		testFiles := map[string][]byte{"flaky_test.cdc": flakyTestScript}
+
+		result, err := testCode(testFiles, state, flagsTests{})
+		require.NoError(t, err)
+		assert.Error(t, result.Results["flaky_test.cdc"][1].Error)
+		assert.Empty(t, result.unstableTests())
+
+		// retries run the tests in the same order, so the order dependent failure isn't hidden
+		result, err = testCode(testFiles, state, flagsTests{Retries: 2})
+		require.NoError(t, err)
+		assert.NoError(t, result.Results["flaky_test.cdc"][0].Error)
+		assert.Error(t, result.Results["flaky_test.cdc"][1].Error)
+		assert.Equal(t, &testRuns{Passed: 0, Failed: 3}, result.TestRuns["flaky_test.cdc"]["testZero"])
+		assert.Empty(t, result.unstableTests())
+	})
+
+	t.Run("with flaky test passing on retry", func(t *testing.T) {
+		t.Parallel()
+
+		failure := errors.New("failed")
+		first := fileResult{results: cdcTests.Results{
+			{TestName: "testPass"},
+			{TestName: "testFlaky", Error: failure},
+			{TestName: "testFail", Error: failure},
+		}}
+
+		reruns := 0
+		rerun := func() fileResult {
+			reruns++
+			return fileResult{results: cdcTests.Results{
+				{TestName: "testPass", Error: failure},
+				{TestName: "testFlaky"},
+				{TestName: "testFail", Error: failure},
+			}}
+		}
+
+		runs := retryFailedTests(rerun, &first, 2, 0)
+		require.NoError(t, first.err)
+		assert.Equal(t, 2, reruns)
+		assert.Equal(t, map[string]*testRuns{
+			"testPass":  {Passed: 1},
+			"testFlaky": {Passed: 1, Failed: 1},
+			"testFail":  {Failed: 3},
+		}, runs)
+
+		// only failed tests are retried
+		assert.NoError(t, first.results[0].Error)
+		assert.NoError(t, first.results[1].Error)
+		assert.Error(t, first.results[2].Error)
+
+		r := &result{
+			Results:  map[string]cdcTests.Results{"flaky_test.cdc": first.results},
+			TestRuns: map[string]map[string]*testRuns{"flaky_test.cdc": runs},
+		}
+		assert.Equal(t, []unstableTest{{
+			File: "flaky_test.cdc",
+			Name: "testFlaky",
+			Runs: &testRuns{Passed: 1, Failed: 1},
+		}}, r.unstableTests())
+		assert.Contains(t, r.String(), "Flaky and failing tests")
+		assert.Equal(t, "flaky_test.cdc:testFlaky", r.JSON().(map[string]map[string]string)[TestReportMetaKey][TestReportFlakyKey])
+	})
+
+	t.Run("with repeated runs", func(t *testing.T) {
+		t.Parallel()
+
+		_, state, _ := util.TestMocks(t)
+
+		testFiles := map[string][]byte{"flaky_test.cdc": flakyTestScript}
+		result, err := testRepeatedly(testFiles, state, flagsTests{Count: 8, Seed: 100})
+		require.NoError(t, err)
+
+		assert.Equal(t, []int64{100, 101, 102, 103, 104, 105, 106, 107}, result.Seeds)
+
+		unstable := result.unstableTests()
+		require.Len(t, unstable, 1)
+		assert.Equal(t, "testZero", unstable[0].Name)
+		assert.True(t, unstable[0].Runs.flaky())
+		assert.Equal(t, 8, unstable[0].Runs.Passed+unstable[0].Runs.Failed)
+		assert.Len(t, unstable[0].Runs.Seeds, unstable[0].Runs.Failed)
+
+		// the failing seeds reproduce the failure
+		reproduced, err := testCode(testFiles, state, flagsTests{Seed: unstable[0].Runs.Seeds[0]})
+		require.NoError(t, err)
+		for _, testResult := range reproduced.Results["flaky_test.cdc"] {
+			if testResult.TestName == "testZero" {
+				assert.Error(t, testResult.Error)
+			}
+		}
+
+		for _, testResult := range result.Results["flaky_test.cdc"] {
+			if testResult.TestName == "testZero" {
+				assert.Error(t, testResult.Error)
+			}
+		}
+		assert.Contains(t, result.String(), "Seeds: 100, 101, 102, 103, 104, 105, 106, 107")
+	})
+
 	t.Run("with missing contract in config", func(t *testing.T) {
 		t.Parallel()
 
@@ -599,6 +847,59 @@ Seed: 1521
 		)
 	})
 
+	t.Run("with parallel test files", func(t *testing.T) {
+		t.Parallel()
+
+		// Setup
+		_, state, _ := util.TestMocks(t)
+
+		state.Contracts().AddOrUpdate(config.Contract{
+			Name:     tests.ContractFooCoverage.Name,
+			Location: tests.ContractFooCoverage.Filename,
+			Aliases:  aliases,
+		})
+
+		// Execute scripts
+		testFiles := map[string][]byte{
+			"a_test.cdc":                           tests.TestScriptWithCoverage.Source,
+			"b_test.cdc":                           tests.TestScriptWithCoverage.Source,
+			tests.TestScriptSimple.Filename:        tests.TestScriptSimple.Source,
+			tests.TestScriptSimpleFailing.Filename: tests.TestScriptSimpleFailing.Source,
+		}
+
+		sequential, err := testCode(testFiles, state, flagsTests{Cover: true, CoverCode: contractsCoverCode})
+		require.NoError(t, err)
+
+		parallel, err := testCode(testFiles, state, flagsTests{Cover: true, CoverCode: contractsCoverCode, Parallel: 4})
+		require.NoError(t, err)
+
+		require.Len(t, parallel.Results, 4)
+		assert.Equal(t, sequential.Oneliner(), parallel.Oneliner())
+		assert.Error(t, parallel.Results[tests.TestScriptSimpleFailing.Filename][0].Error)
+
+		// coverage of the contract used by both files is summed
+		single, err := testCode(
+			map[string][]byte{"a_test.cdc": tests.TestScriptWithCoverage.Source},
+			state,
+			flagsTests{Cover: true, CoverCode: contractsCoverCode},
+		)
+		require.NoError(t, err)
+
+		location := common.AddressLocation{
+			Address: common.Address{0, 0, 0, 0, 0, 0, 0, 7},
+			Name:    tests.ContractFooCoverage.Name,
+		}
+		singleCoverage := single.CoverageReport.Coverage[location]
+		parallelCoverage := parallel.CoverageReport.Coverage[location]
+		require.NotNil(t, singleCoverage)
+		require.NotNil(t, parallelCoverage)
+		for line, hits := range singleCoverage.LineHits {
+			assert.Equal(t, 2*hits, parallelCoverage.LineHits[line])
+		}
+		assert.Equal(t, singleCoverage.Statements, parallelCoverage.Statements)
+		assert.Equal(t, sequential.CoverageReport.Percentage(), parallel.CoverageReport.Percentage())
+	})
+
 	t.Run("run specific test case by name", func(t *testing.T) {
 		t.Parallel()
 
@@ -668,7 +969,7 @@ Seed: 1521
 		)
 	})
 
-	t.Run("run specific test case by name will do nothing if not found", func(t *testing.T) {
+	t.Run("run specific test case by name fails if not found", func(t *testing.T) {
 		t.Parallel()
 
 		// Setup
@@ -683,14 +984,438 @@ Seed: 1521
 			Name: "doesNotExist",
 		}
 
-		result, err := testCode(testFiles, state, flags)
+		_, err := testCode(testFiles, state, flags)
 
-		assert.NoError(t, err)
-		assert.Len(t, result.Results, 0)
-		assert.Equal(
-			t,
-			"No tests found",
-			result.Oneliner(),
-		)
+		assert.EqualError(t, err, "no tests matched --name doesNotExist in 1 test files")
+	})
+
+	t.Run("run test cases matching regular expressions", func(t *testing.T) {
+		t.Parallel()
+
+		// Setup
+		_, state, _ := util.TestMocks(t)
+
+		state.Contracts().AddOrUpdate(config.Contract{
+			Name:     tests.ContractFooCoverage.Name,
+			Location: tests.ContractFooCoverage.Filename,
+			Aliases:  aliases,
+		})
+
+		// Execute scripts
+		testFiles := map[string][]byte{
+			tests.TestScriptWithCoverage.Filename:  tests.TestScriptWithCoverage.Source,
+			tests.TestScriptSimple.Filename:        tests.TestScriptSimple.Source,
+			tests.TestScriptSimpleFailing.Filename: tests.TestScriptSimpleFailing.Source,
+		}
+
+		result, err := testCode(testFiles, state, flagsTests{Run: "^test(Get|Simple)"})
+		require.NoError(t, err)
+		require.Len(t, result.Results, 3)
+		assert.Equal(t, "testGetIntegerTrait", result.Results[tests.TestScriptWithCoverage.Filename][0].TestName)
+		require.Len(t, result.Results[tests.TestScriptWithCoverage.Filename], 1)
+
+		result, err = testCode(testFiles, state, flagsTests{Run: "Integer|Simple", Skip: "Simple"})
+		require.NoError(t, err)
+		require.Len(t, result.Results, 1)
+		assert.Equal(t, "testGetIntegerTrait", result.Results[tests.TestScriptWithCoverage.Filename][0].TestName)
+
+		_, err = testCode(testFiles, state, flagsTests{Skip: "."})
+		assert.EqualError(t, err, "no tests matched --skip . in 3 test files")
+
+		_, err = testCode(testFiles, state, flagsTests{Run: "("})
+		assert.ErrorContains(t, err, "invalid '--run' regular expression")
+	})
+}
+
+func TestDiscoverTestFiles(t *testing.T) {
+	t.Parallel()
+
+	_, _, rw := util.TestMocks(t)
+	for _, file := range []string{
+		"cadence/tests/a_test.cdc",
+		"cadence/tests/nested/b_test.cdc",
+		"cadence/tests/helper.cdc",
+		"cadence/legacy/c_test.cdc",
+		"node_modules/pkg/d_test.cdc",
+		"e_test.cdc",
+	} {
+		require.NoError(t, rw.WriteFile(file, []byte(""), 0644))
+	}
+
+	t.Run("all files", func(t *testing.T) {
+		files, err := discoverTestFiles(rw, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"cadence/legacy/c_test.cdc",
+			"cadence/tests/a_test.cdc",
+			"cadence/tests/nested/b_test.cdc",
+			"e_test.cdc",
+		}, files)
+	})
+
+	t.Run("recursive pattern", func(t *testing.T) {
+		files, err := discoverTestFiles(rw, nil, []string{"./cadence/tests/..."})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cadence/tests/a_test.cdc", "cadence/tests/nested/b_test.cdc"}, files)
+	})
+
+	t.Run("directory and file", func(t *testing.T) {
+		files, err := discoverTestFiles(rw, nil, []string{"cadence/tests", "cadence/tests/helper.cdc"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cadence/tests/a_test.cdc", "cadence/tests/helper.cdc"}, files)
+	})
+
+	t.Run("no test files", func(t *testing.T) {
+		_, err := discoverTestFiles(rw, nil, []string{"cadence/tests/nested/..."})
+		require.NoError(t, err)
+
+		require.NoError(t, rw.MkdirAll("empty", 0755))
+		_, err = discoverTestFiles(rw, nil, []string{"./empty/..."})
+		assert.ErrorContains(t, err, "no test files found")
+	})
+}
+
+func TestDiscoverTestFilesIgnore(t *testing.T) {
+	t.Parallel()
+
+	_, _, rw := util.TestMocks(t)
+	for _, file := range []string{
+		"cadence/tests/a_test.cdc",
+		"cadence/tests/slow_test.cdc",
+		"cadence/legacy/c_test.cdc",
+	} {
+		require.NoError(t, rw.WriteFile(file, []byte(""), 0644))
+	}
+	require.NoError(t, rw.WriteFile("flow.json", []byte(`{"test": {"ignore": ["/cadence/legacy/", "", "slow_*.cdc"]}}`), 0644))
+
+	files, err := discoverTestFiles(rw, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cadence/tests/a_test.cdc"}, files)
+
+	files, err = discoverTestFiles(rw, nil, []string{"cadence/tests/slow_test.cdc"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cadence/tests/slow_test.cdc"}, files, "explicit files are never ignored")
+}
+
+func TestReporters(t *testing.T) {
+	t.Parallel()
+
+	r := &result{
+		Results: map[string]cdcTests.Results{
+			"b_test.cdc": {{TestName: "testFail", Error: errors.New("assertion failed\n --> b_test.cdc:5:12")}},
+			"a_test.cdc": {{TestName: "testPass"}},
+		},
+		Durations: map[string]time.Duration{
+			"a_test.cdc": 1500 * time.Millisecond,
+			"b_test.cdc": 250 * time.Millisecond,
+		},
+		TestDurations: map[string]map[string]time.Duration{
+			"a_test.cdc": {"testPass": 1200 * time.Millisecond},
+		},
+		RandomSeed: 42,
+	}
+
+	t.Run("junit", func(t *testing.T) {
+		t.Parallel()
+
+		output, err := report(r, junitReporter)
+		require.NoError(t, err)
+
+		expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="2" failures="1" time="1.750">
+  <testsuite name="a_test.cdc" file="a_test.cdc" tests="1" failures="0" time="1.500">
+    <testcase name="testPass" classname="a_test" file="a_test.cdc" time="1.200"></testcase>
+  </testsuite>
+  <testsuite name="b_test.cdc" file="b_test.cdc" tests="1" failures="1" time="0.250">
+    <testcase name="testFail" classname="b_test" file="b_test.cdc">
+      <failure message="assertion failed">assertion failed&#xA; --&gt; b_test.cdc:5:12</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+		assert.Equal(t, expected, string(output))
+	})
+
+	t.Run("tap", func(t *testing.T) {
+		t.Parallel()
+
+		output, err := report(r, tapReporter)
+		require.NoError(t, err)
+
+		expected := `TAP version 13
+1..2
+ok 1 - a_test.cdc: testPass
+not ok 2 - b_test.cdc: testFail
+  ---
+  message: |
+    assertion failed
+     --> b_test.cdc:5:12
+  file: "b_test.cdc"
+  ...
+`
+		assert.Equal(t, expected, string(output))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		output, err := report(r, jsonReporter)
+		require.NoError(t, err)
+
+		expected := `{
+			"files": [
+				{"file": "a_test.cdc", "duration": 1.5, "tests": [{"name": "testPass", "passed": true}]},
+				{"file": "b_test.cdc", "duration": 0.25, "tests": [{"name": "testFail", "passed": false, "error": "assertion failed\n --> b_test.cdc:5:12"}]}
+			],
+			"seed": 42
+		}`
+		assert.JSONEq(t, expected, string(output))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := report(r, "html")
+		assert.ErrorContains(t, err, "unsupported reporter html")
+	})
+}
+
+func TestCoverageThresholds(t *testing.T) {
+	t.Parallel()
+
+	thresholds, err := parseCoverageThresholds([]string{"Foo=90", "Bar=50%"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"Foo": 90, "Bar": 50}, thresholds)
+
+	_, err = parseCoverageThresholds([]string{"Foo=high"})
+	assert.ErrorContains(t, err, "invalid coverage threshold Foo=high")
+
+	report := runtime.NewCoverageReport()
+	foo := common.AddressLocation{Address: common.Address{7}, Name: "Foo"}
+	bar := common.AddressLocation{Address: common.Address{7}, Name: "Bar"}
+	report.Coverage[foo] = runtime.NewLocationCoverage(map[int]int{1: 1, 2: 1, 3: 0, 4: 1})
+	report.Coverage[bar] = runtime.NewLocationCoverage(map[int]int{1: 1, 2: 0})
+	report.Coverage[common.StringLocation("script.cdc")] = runtime.NewLocationCoverage(map[int]int{1: 0, 2: 0})
+
+	shortfalls := checkCoverage(report, 80, thresholds)
+	assert.Equal(t, []coverageShortfall{
+		{Name: "Foo", Coverage: 75, Minimum: 90, MissedLines: []int{3}},
+		{Name: totalCoverageName, Coverage: 50, Minimum: 80},
+	}, shortfalls)
+
+	table := formatShortfalls(shortfalls)
+	assert.Contains(t, table, "Foo")
+	assert.Contains(t, table, "75.0%")
+	assert.Contains(t, table, "90.0%")
+
+	assert.Empty(t, checkCoverage(report, 0, map[string]float64{"Foo": 75}))
+
+	t.Run("from configuration", func(t *testing.T) {
+		_, _, rw := util.TestMocks(t)
+
+		conf, err := loadTestConfig(rw, nil)
+		require.NoError(t, err)
+		assert.Empty(t, conf.coverageThresholds(nil))
+
+		require.NoError(t, rw.WriteFile("flow.json", []byte(`{"test": {"coverageThresholds": {"Foo": 70, "Bar": 40}}}`), 0644))
+		conf, err = loadTestConfig(rw, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]float64{"Foo": 90, "Bar": 40}, conf.coverageThresholds(map[string]float64{"Foo": 90}))
+
+		require.NoError(t, rw.WriteFile("flow.json", []byte(`{"test": {"coverageThresholds": {"Foo": 170}}}`), 0644))
+		_, err = loadTestConfig(rw, nil)
+		assert.ErrorContains(t, err, "invalid coverage threshold 170 of Foo in the test configuration")
+
+		// the configuration files given with the config flag are read, the last one with a test section is used
+		require.NoError(t, rw.WriteFile("other.json", []byte(`{"test": {"coverageThresholds": {"Bar": 50}}}`), 0644))
+		conf, err = loadTestConfig(rw, []string{"other.json"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]float64{"Bar": 50}, conf.coverageThresholds(nil))
+
+		require.NoError(t, rw.WriteFile("flow.json", []byte(`{"test": {"coverageThresholds": {"Foo": 70}}}`), 0644))
+		conf, err = loadTestConfig(rw, []string{"flow.json", "other.json"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]float64{"Bar": 50}, conf.coverageThresholds(nil))
+	})
+}
+
+func TestCoverageHTML(t *testing.T) {
+	t.Parallel()
+
+	_, state, rw := util.TestMocks(t)
+
+	source := "access(all) contract Foo {\n    access(all) fun hello(): String {\n        return \"hello\"\n    }\n    access(all) fun bye() {}\n}\n"
+	require.NoError(t, rw.WriteFile("contracts/Foo.cdc", []byte(source), 0644))
+	state.Contracts().AddOrUpdate(config.Contract{
+		Name:     "Foo",
+		Location: "contracts/Foo.cdc",
+	})
+
+	report := runtime.NewCoverageReport()
+	foo := common.AddressLocation{Address: common.Address{7}, Name: "Foo"}
+	report.Coverage[foo] = runtime.NewLocationCoverage(map[int]int{3: 2, 5: 0})
+	report.Coverage[common.StringLocation("missing.cdc")] = runtime.NewLocationCoverage(map[int]int{1: 1})
+
+	report.Coverage[common.StringLocation("scripts/get.cdc")] = runtime.NewLocationCoverage(map[int]int{1: 1})
+
+	pages, err := coverageHTML(report, "out/coverage.html", state.ReaderWriter(), state.Contracts())
+	require.NoError(t, err)
+	require.Len(t, pages, 4)
+
+	index := string(pages["out/coverage.html"])
+	assert.Contains(t, index, `<a href="coverage/Foo.html">Foo</a>`)
+	assert.Contains(t, index, `<a href="coverage/missing.cdc.html">missing.cdc</a>`)
+	assert.Contains(t, index, `<a href="coverage/scripts_get.cdc.html">scripts/get.cdc</a>`)
+	assert.NotContains(t, index, `<table class="source">`)
+
+	fooPage := string(pages[filepath.Join("out", "coverage", "Foo.html")])
+	assert.Contains(t, fooPage, `<a href="../coverage.html">All files</a>`)
+	assert.Contains(t, fooPage, `contracts/Foo.cdc`)
+	assert.Contains(t, fooPage, `<tr class="hit"><td class="number">3</td><td class="hits">2</td><td class="code">        return &#34;hello&#34;</td></tr>`)
+	assert.Contains(t, fooPage, `<tr class="miss"><td class="number">5</td><td class="hits">0</td>`)
+	assert.Contains(t, fooPage, `<tr class=""><td class="number">1</td><td class="hits"></td><td class="code">access(all) contract Foo {</td></tr>`)
+	assert.Contains(t, string(pages[filepath.Join("out", "coverage", "missing.cdc.html")]), `source not available`)
+
+	assert.Equal(t, "Foo-2.html", coveragePageName("Foo", map[string]bool{"Foo.html": true}))
+
+	require.NoError(t, writeCoverage(report, "out/coverage.html", rw, state.Contracts()))
+	written, err := rw.ReadFile(filepath.Join("out", "coverage", "Foo.html"))
+	require.NoError(t, err)
+	assert.Equal(t, fooPage, string(written))
+}
+
+func TestMergeCoverage(t *testing.T) {
+	_, state, rw := util.TestMocks(t)
+
+	foo := common.AddressLocation{Address: common.Address{7}, Name: "Foo"}
+	bar := common.AddressLocation{Address: common.Address{7}, Name: "Bar"}
+	script := common.StringLocation("script")
+
+	// contracts are keyed by their source path, as in reports written by flow test
+	first := runtime.NewCoverageReport()
+	first.WithLocationMappings(map[string]string{"Foo": "contracts/Foo.cdc"})
+	first.Coverage[foo] = runtime.NewLocationCoverage(map[int]int{1: 1, 2: 0, 3: 0})
+	first.Coverage[script] = runtime.NewLocationCoverage(map[int]int{1: 1})
+	second := runtime.NewCoverageReport()
+	second.WithLocationMappings(map[string]string{"Foo": "contracts/Foo.cdc"})
+	second.Coverage[foo] = runtime.NewLocationCoverage(map[int]int{1: 2, 2: 1, 3: 0})
+	second.Coverage[bar] = runtime.NewLocationCoverage(map[int]int{1: 0})
+
+	for filename, report := range map[string]*runtime.CoverageReport{"a.json": first, "b.json": second} {
+		data, err := json.Marshal(report)
+		require.NoError(t, err)
+		require.NoError(t, rw.WriteFile(filename, data, 0644))
+	}
+	require.NoError(t, rw.WriteFile("a.lcov", []byte("TN:\n"), 0644))
+
+	contracts := &config.Contracts{}
+	report, err := mergeCoverageFiles(rw, []string{"a.json", "b.json"}, contracts)
+	require.NoError(t, err)
+
+	merged := common.AddressLocation{Name: "Foo"}
+	assert.Equal(t, map[int]int{1: 3, 2: 1, 3: 0}, report.Coverage[merged].LineHits)
+	assert.Equal(t, 3, report.Coverage[merged].Statements)
+	assert.Equal(t, map[int]int{1: 1}, report.Coverage[script].LineHits)
+	assert.Equal(t, map[int]int{1: 0}, report.Coverage[bar].LineHits)
+	assert.Equal(t, []coverageShortfall{
+		{Name: "Bar", Coverage: 0, Minimum: 50, MissedLines: []int{1}},
+		{Name: "Foo", Coverage: 200.0 / 3, Minimum: 70, MissedLines: []int{3}},
+	}, checkCoverage(report, 50, map[string]float64{"Foo": 70}))
+
+	contract, err := contracts.ByName("Foo")
+	require.NoError(t, err)
+	assert.Equal(t, "contracts/Foo.cdc", contract.Location)
+
+	_, err = mergeCoverageFiles(rw, []string{"a.json", "a.lcov"}, contracts)
+	assert.ErrorContains(t, err, "error parsing coverage report a.lcov, only JSON reports can be merged")
+
+	_, err = mergeCoverageFiles(rw, []string{"missing.json"}, contracts)
+	assert.ErrorContains(t, err, "error loading coverage report file")
+
+	// the flags are shared by the command, they are restored for the other tests
+	defer func(flags flagsMerge) { mergeFlags = flags }(mergeFlags)
+	mergeFlags = flagsMerge{CoverProfile: "total.lcov"}
+	result, err := merge([]string{"a.json", "b.json"}, command.GlobalFlags{}, nil, state.ReaderWriter(), nil)
+	require.NoError(t, err)
+	assert.Contains(t, result.String(), "Merged 2 coverage reports into total.lcov")
+	assert.Contains(t, result.String(), "Coverage: 60.0% of statements")
+
+	lcov, err := rw.ReadFile("total.lcov")
+	require.NoError(t, err)
+	assert.Contains(t, string(lcov), "SF:contracts/Foo.cdc\nDA:1,3\nDA:2,1\nDA:3,0\n")
+
+	mergeFlags = flagsMerge{CoverProfile: "total.json"}
+	_, err = merge([]string{"a.json", "b.json"}, command.GlobalFlags{}, nil, state.ReaderWriter(), nil)
+	require.NoError(t, err)
+
+	total, err := mergeCoverageFiles(rw, []string{"total.json"}, &config.Contracts{})
+	require.NoError(t, err)
+	assert.Equal(t, report.Coverage, total.Coverage)
+}
+
+func TestTestDependencies(t *testing.T) {
+	t.Parallel()
+
+	_, state, rw := util.TestMocks(t)
+
+	for _, path := range []string{
+		"imports/0000000000000abc/Foo.cdc",
+		"imports/0000000000000abc/Bar.cdc",
+		"imports/0000000000000def/Baz.cdc",
+		"imports/f233dcee88fe0abe/FungibleToken.cdc",
+		"imports/github.com/onflow/repo/Ignored.cdc",
+		"imports/0000000000000def/Quux.cdc",
+	} {
+		require.NoError(t, rw.WriteFile(path, []byte("pub contract C {}"), 0644))
+	}
+	state.Contracts().AddOrUpdate(config.Contract{Name: "Bar", Location: "contracts/Bar.cdc"})
+	state.Dependencies().AddOrUpdate(config.Dependency{
+		Name:   "Qux",
+		Source: config.Source{NetworkName: "testnet", Address: flow.HexToAddress("0def"), ContractName: "Quux"},
 	})
+
+	last := common.Address{0, 0, 0, 0, 0, 0, 0, 0x18}
+	deps, err := newTestDependencies(state, map[string]common.Address{"Other": last})
+	require.NoError(t, err)
+
+	// contracts from the same account share a testing account, core contracts are provided by the framework
+	assert.Equal(t, map[string]common.Address{
+		"Foo": {0, 0, 0, 0, 0, 0, 0, 0x17},
+		"Bar": {0, 0, 0, 0, 0, 0, 0, 0x17},
+		"Baz": {0, 0, 0, 0, 0, 0, 0, 0x16},
+		"Qux": {0, 0, 0, 0, 0, 0, 0, 0x16},
+	}, deps.aliases)
+
+	location, ok := deps.location("Baz")
+	assert.True(t, ok)
+	assert.Equal(t, "imports/0000000000000def/Baz.cdc", location)
+	// a dependency installed under a different name is read from the file of its source contract
+	location, ok = deps.location("Qux")
+	assert.True(t, ok)
+	assert.Equal(t, "imports/0000000000000def/Quux.cdc", location)
+	_, ok = deps.location("Quux")
+	assert.False(t, ok)
+	_, ok = deps.location("Ignored")
+	assert.False(t, ok)
+
+	deps, err = newTestDependencies(state, map[string]common.Address{"Foo": last})
+	require.NoError(t, err)
+	assert.NotContains(t, deps.aliases, "Foo")
+	assert.Equal(t, common.Address{0, 0, 0, 0, 0, 0, 0, 0x17}, deps.aliases["Bar"])
+
+	code := deps.rewriteImports(`import Foo from 0x0000000000000abc
+import FungibleToken from 0xf233dcee88fe0abe
+import Other from 0x0000000000000abc
+import Quux from 0x0000000000000def
+import "Baz"
+
+pub contract C {}`)
+	// the renamed dependency is imported by the name it was installed as, which has the testing alias
+	assert.Equal(t, `import Foo from "Foo"
+import FungibleToken from "FungibleToken"
+import Other from 0x0000000000000abc
+import Quux from "Qux"
+import "Baz"
+
+pub contract C {}`, code)
 }
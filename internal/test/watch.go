@@ -0,0 +1,321 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2022 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/radovskyb/watcher"
+	"golang.org/x/exp/slices"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/output"
+
+	"github.com/onflow/flow-cli/internal/command"
+)
+
+// Changes within this window after the first one are handled together, editors often write a file more than once.
+const watchDebounce = 200 * time.Millisecond
+
+// fileSet records the files read while running a test file, so watch mode knows which tests to re-run.
+type fileSet struct {
+	mutex sync.Mutex
+	paths map[string]bool
+}
+
+func newFileSet(paths ...string) *fileSet {
+	set := &fileSet{paths: make(map[string]bool)}
+	for _, path := range paths {
+		set.add(path)
+	}
+
+	return set
+}
+
+func (s *fileSet) add(path string) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.paths[filepath.Clean(path)] = true
+}
+
+func (s *fileSet) contains(path string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.paths[filepath.Clean(path)]
+}
+
+func (s *fileSet) list() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	paths := make([]string, 0, len(s.paths))
+	for path := range s.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+// affectedTests returns the test files that read any of the changed files.
+func affectedTests(dependencies map[string]*fileSet, changed []string) []string {
+	affected := make([]string, 0)
+	for testFile, files := range dependencies {
+		for _, path := range changed {
+			if files.contains(path) {
+				affected = append(affected, testFile)
+				break
+			}
+		}
+	}
+	sort.Strings(affected)
+
+	return affected
+}
+
+// loadTestFiles reads the code of the test files.
+func loadTestFiles(state *flowkit.State, filenames []string) (map[string][]byte, error) {
+	testFiles := make(map[string][]byte, len(filenames))
+	for _, filename := range filenames {
+		code, err := state.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("error loading script file: %w", err)
+		}
+
+		testFiles[filename] = code
+	}
+
+	return testFiles, nil
+}
+
+// watch runs the tests and then re-runs the test files affected by each change until interrupted.
+//
+// A change to the configuration reloads it and re-runs all tests, new test files matching the arguments are run when created.
+func watch(
+	args []string,
+	globalFlags command.GlobalFlags,
+	logger output.Logger,
+	state *flowkit.State,
+	flags flagsTests,
+) error {
+	filenames, err := discoverTestFiles(state.ReaderWriter(), globalFlags.ConfigPaths, args)
+	if err != nil {
+		return err
+	}
+
+	w := watcher.New()
+	w.FilterOps(watcher.Write, watcher.Create, watcher.Remove, watcher.Rename, watcher.Move)
+	watched := make(map[string]bool)
+
+	watchPath := func(path string) {
+		if watched[path] {
+			return
+		}
+		if err := w.Add(path); err == nil {
+			watched[path] = true
+		}
+	}
+
+	dependencies := make(map[string]*fileSet)
+	runTests := func(filenames []string) {
+		setStatus(0)
+
+		testFiles, err := loadTestFiles(state, filenames)
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+
+		result, err := testCode(testFiles, state, flags)
+		if err != nil {
+			logger.Error(err.Error())
+			return
+		}
+
+		for testFile, files := range result.dependencies {
+			dependencies[testFile] = files
+			for _, path := range files.list() {
+				watchPath(filepath.Dir(path))
+			}
+		}
+		logger.Info(watchSummary(result))
+	}
+
+	for _, configPath := range globalFlags.ConfigPaths {
+		watchPath(configPath)
+	}
+	runTests(filenames)
+
+	go func() {
+		if err := w.Start(500 * time.Millisecond); err != nil {
+			logger.Error(fmt.Sprintf("error watching files: %v", err))
+			w.Close()
+		}
+	}()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("%s Watching %d test files for changes, press Ctrl+C to stop.", output.GoEmoji(), len(filenames)))
+
+	for {
+		var event watcher.Event
+		select {
+		case event = <-w.Event:
+		case err := <-w.Error:
+			logger.Error(fmt.Sprintf("error watching files: %v", err))
+			continue
+		case <-interrupt:
+			w.Close()
+			return nil
+		case <-w.Closed:
+			return nil
+		}
+
+		changed := []string{event.Path, event.OldPath}
+		debounce := time.After(watchDebounce)
+	collect:
+		for {
+			select {
+			case event := <-w.Event:
+				changed = append(changed, event.Path, event.OldPath)
+			case <-debounce:
+				break collect
+			}
+		}
+
+		changedPaths := make([]string, 0, len(changed))
+		configChanged := false
+		for _, path := range changed {
+			if path == "" {
+				continue
+			}
+			if rel, err := filepath.Rel(cwd, path); err == nil {
+				path = rel
+			}
+			changedPaths = append(changedPaths, path)
+
+			for _, configPath := range globalFlags.ConfigPaths {
+				if filepath.Clean(configPath) == filepath.Clean(path) {
+					configChanged = true
+				}
+			}
+		}
+
+		current, err := discoverTestFiles(state.ReaderWriter(), globalFlags.ConfigPaths, args)
+		if err != nil {
+			logger.Error(err.Error())
+			continue
+		}
+
+		if configChanged {
+			reloaded, err := flowkit.Load(globalFlags.ConfigPaths, state.ReaderWriter())
+			if err != nil {
+				logger.Error(fmt.Sprintf("error loading configuration: %v", err))
+				continue
+			}
+			state = reloaded
+			logger.Info(fmt.Sprintf("%s Configuration changed, running all tests", output.TryEmoji()))
+			runTests(current)
+			continue
+		}
+
+		// new test files weren't read before, so they are added explicitly
+		affected := affectedTests(dependencies, changedPaths)
+		for _, testFile := range current {
+			if _, ok := dependencies[testFile]; !ok {
+				affected = append(affected, testFile)
+			}
+		}
+
+		rerun := make([]string, 0, len(affected))
+		for _, testFile := range affected {
+			if slices.Contains(current, testFile) {
+				rerun = append(rerun, testFile)
+			} else {
+				delete(dependencies, testFile) // removed or no longer matching
+			}
+		}
+		if len(rerun) == 0 {
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("%s Changed %s, running %d test files", output.TryEmoji(), strings.Join(changedPaths, ", "), len(rerun)))
+		runTests(rerun)
+	}
+}
+
+// watchSummary is a compact summary of the results listing only the failed tests.
+func watchSummary(r *result) string {
+	passed, failed := 0, 0
+	var failures strings.Builder
+
+	for _, testFile := range r.sortedFiles() {
+		for _, testResult := range r.Results[testFile] {
+			if testResult.Error == nil {
+				passed++
+				continue
+			}
+			failed++
+			failures.WriteString(fmt.Sprintf("\n  %s %s: %s", output.ErrorEmoji(), testFile, testResult.TestName))
+		}
+	}
+
+	var duration time.Duration
+	for _, d := range r.Durations {
+		duration += d
+	}
+
+	summary := fmt.Sprintf("%s %d passed", output.OkEmoji(), passed)
+	if failed > 0 {
+		summary = fmt.Sprintf("%s, %s", summary, output.Red(fmt.Sprintf("%d failed", failed)))
+	}
+
+	return fmt.Sprintf(
+		"[%s] %s in %d files (%s)%s",
+		time.Now().Format("15:04:05"),
+		summary,
+		len(r.Results),
+		duration.Round(time.Millisecond),
+		failures.String(),
+	)
+}
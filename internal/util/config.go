@@ -0,0 +1,274 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"golang.org/x/exp/slices"
+
+	"github.com/onflow/flowkit"
+	"github.com/onflow/flowkit/config"
+)
+
+// flowkitConfigKeys are the top level keys of the configuration that are managed by flowkit.
+//
+// Flowkit drops any other keys when it saves the configuration, so the sections the CLI adds
+// to flow.json are read and written with ReadConfigSection and WriteConfigSection and are kept
+// by the ConfigReaderWriter when flowkit saves.
+var flowkitConfigKeys = []string{"emulators", "contracts", "networks", "accounts", "deployments", "dependencies"}
+
+// configEntry is a top level key of the configuration with its raw value.
+type configEntry struct {
+	key   string
+	value json.RawMessage
+}
+
+// parseConfigEntries returns the top level entries of the configuration in the order of the file.
+func parseConfigEntries(data []byte) ([]configEntry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("configuration is not a JSON object")
+	}
+
+	entries := make([]configEntry, 0)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, configEntry{key: token.(string), value: value})
+	}
+
+	return entries, nil
+}
+
+func serializeConfigEntries(entries []configEntry) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("{")
+	for i, entry := range entries {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		key, _ := json.Marshal(entry.key)
+		b.Write(key)
+		b.WriteString(":")
+		b.Write(entry.value)
+	}
+	b.WriteString("}")
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, b.Bytes(), "", "\t"); err != nil {
+		return nil, err
+	}
+
+	return indented.Bytes(), nil
+}
+
+// ConfigEditPath returns the configuration file which is written when the configuration on the paths is edited.
+//
+// Like flowkit, the local flow.json is edited for the default paths and editing is only supported for a single
+// configuration file otherwise.
+func ConfigEditPath(paths []string) (string, error) {
+	if len(paths) == 0 || config.IsDefaultPath(paths) {
+		return config.DefaultPath, nil
+	}
+	if len(paths) > 1 {
+		return "", fmt.Errorf("specifying multiple paths is not supported when updating configuration")
+	}
+
+	return paths[0], nil
+}
+
+// ReadConfigSection decodes the section with the key from the configuration files on the paths into v,
+// it returns false if there is no such section.
+//
+// Missing configuration files are skipped and if several files have the section, the last one is used,
+// the same as flowkit does when it merges the configuration files.
+func ReadConfigSection(rw flowkit.ReaderWriter, paths []string, key string, v any) (bool, error) {
+	if len(paths) == 0 {
+		paths = []string{config.DefaultPath}
+	}
+
+	var section json.RawMessage
+	var sectionPath string
+	for _, path := range paths {
+		data, err := rw.ReadFile(path)
+		if err != nil {
+			continue // no configuration, so no section
+		}
+
+		entries, err := parseConfigEntries(data)
+		if err != nil {
+			return false, fmt.Errorf("error parsing %s: %w", path, err)
+		}
+
+		for _, entry := range entries {
+			if entry.key == key {
+				section, sectionPath = entry.value, path
+			}
+		}
+	}
+
+	if section == nil {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(section, v); err != nil {
+		return false, fmt.Errorf("error parsing %s in %s: %w", key, sectionPath, err)
+	}
+
+	return true, nil
+}
+
+// WriteConfigSection encodes v as the section with the key in the configuration file edited for the paths,
+// see ConfigEditPath. The section is removed if v is nil.
+func WriteConfigSection(rw flowkit.ReaderWriter, paths []string, key string, v any) error {
+	if slices.Contains(flowkitConfigKeys, key) {
+		return fmt.Errorf("%s is managed by flowkit", key)
+	}
+
+	path, err := ConfigEditPath(paths)
+	if err != nil {
+		return err
+	}
+
+	data, err := rw.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	entries, err := parseConfigEntries(data)
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %w", path, err)
+	}
+
+	index := slices.IndexFunc(entries, func(entry configEntry) bool {
+		return entry.key == key
+	})
+	if v == nil {
+		if index < 0 {
+			return nil
+		}
+		entries = slices.Delete(entries, index, index+1)
+	} else {
+		value, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("error serializing %s: %w", key, err)
+		}
+		if index < 0 {
+			entries = append(entries, configEntry{key: key, value: value})
+		} else {
+			entries[index].value = value
+		}
+	}
+
+	data, err = serializeConfigEntries(entries)
+	if err != nil {
+		return fmt.Errorf("error serializing %s: %w", path, err)
+	}
+
+	// the sections are written as provided, so they must not be kept from the previous content
+	if configRW, ok := rw.(*ConfigReaderWriter); ok {
+		return configRW.Afero.WriteFile(path, data, 0644)
+	}
+
+	return rw.WriteFile(path, data, 0644)
+}
+
+// ConfigReaderWriter reads and writes files and keeps the sections the CLI adds to the configuration
+// files when flowkit saves them.
+type ConfigReaderWriter struct {
+	*afero.Afero
+	paths []string
+}
+
+// NewConfigReaderWriter creates a reader writer which keeps the CLI sections of the configuration files on the paths.
+func NewConfigReaderWriter(af *afero.Afero, paths []string) *ConfigReaderWriter {
+	cleaned := make([]string, 0, len(paths))
+	for _, path := range paths {
+		cleaned = append(cleaned, filepath.Clean(path))
+	}
+
+	return &ConfigReaderWriter{Afero: af, paths: cleaned}
+}
+
+// WriteFile writes the data to the file, writes of a configuration file keep the sections that are not managed by flowkit.
+func (rw *ConfigReaderWriter) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	if !slices.Contains(rw.paths, filepath.Clean(filename)) {
+		return rw.Afero.WriteFile(filename, data, perm)
+	}
+
+	existing, err := rw.Afero.ReadFile(filename)
+	if err != nil {
+		return rw.Afero.WriteFile(filename, data, perm)
+	}
+
+	kept, err := keepConfigSections(existing, data)
+	if err != nil {
+		return rw.Afero.WriteFile(filename, data, perm) // not a configuration, so there is nothing to keep
+	}
+
+	return rw.Afero.WriteFile(filename, kept, perm)
+}
+
+// keepConfigSections adds the sections of the existing configuration that are not managed by flowkit to the data.
+func keepConfigSections(existing []byte, data []byte) ([]byte, error) {
+	existingEntries, err := parseConfigEntries(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := parseConfigEntries(data)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := false
+	for _, entry := range existingEntries {
+		if slices.Contains(flowkitConfigKeys, entry.key) {
+			continue
+		}
+
+		written := slices.ContainsFunc(entries, func(e configEntry) bool {
+			return e.key == entry.key
+		})
+		if !written {
+			entries = append(entries, entry)
+			kept = true
+		}
+	}
+
+	if !kept {
+		return data, nil
+	}
+
+	return serializeConfigEntries(entries)
+}
@@ -0,0 +1,101 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flowkit/config"
+)
+
+func Test_ConfigSections(t *testing.T) {
+	newReaderWriter := func(t *testing.T, paths []string) *ConfigReaderWriter {
+		rw := NewConfigReaderWriter(&afero.Afero{Fs: afero.NewMemMapFs()}, paths)
+		require.NoError(t, rw.Afero.WriteFile("flow.json", []byte(`{"contracts": {}, "test": {"ignore": ["a"]}}`), 0644))
+		require.NoError(t, rw.Afero.WriteFile("other.json", []byte(`{"contracts": {}}`), 0644))
+		return rw
+	}
+
+	type section struct {
+		Ignore []string `json:"ignore"`
+	}
+
+	t.Run("Read", func(t *testing.T) {
+		rw := newReaderWriter(t, config.DefaultPaths())
+
+		var s section
+		found, err := ReadConfigSection(rw, config.DefaultPaths(), "test", &s)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, []string{"a"}, s.Ignore)
+
+		found, err = ReadConfigSection(rw, []string{"other.json"}, "test", &s)
+		require.NoError(t, err)
+		assert.False(t, found)
+
+		require.NoError(t, rw.Afero.WriteFile("other.json", []byte(`{"test": {"ignore": ["b"]}}`), 0644))
+		found, err = ReadConfigSection(rw, []string{"flow.json", "other.json", "missing.json"}, "test", &s)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, []string{"b"}, s.Ignore)
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		rw := newReaderWriter(t, []string{"other.json"})
+
+		require.NoError(t, WriteConfigSection(rw, []string{"other.json"}, "test", section{Ignore: []string{"b"}}))
+		data, err := rw.ReadFile("other.json")
+		require.NoError(t, err)
+		assert.Equal(t, "{\n\t\"contracts\": {},\n\t\"test\": {\n\t\t\"ignore\": [\n\t\t\t\"b\"\n\t\t]\n\t}\n}", string(data))
+
+		data, err = rw.ReadFile("flow.json")
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"ignore": ["a"]`, "other configuration files are not changed")
+
+		require.NoError(t, WriteConfigSection(rw, config.DefaultPaths(), "test", nil))
+		data, err = rw.ReadFile("flow.json")
+		require.NoError(t, err)
+		assert.Equal(t, "{\n\t\"contracts\": {}\n}", string(data))
+
+		err = WriteConfigSection(rw, []string{"flow.json", "other.json"}, "test", nil)
+		assert.ErrorContains(t, err, "specifying multiple paths is not supported")
+
+		err = WriteConfigSection(rw, nil, "contracts", nil)
+		assert.ErrorContains(t, err, "contracts is managed by flowkit")
+	})
+
+	t.Run("Kept when flowkit saves", func(t *testing.T) {
+		rw := newReaderWriter(t, []string{"flow.json"})
+
+		require.NoError(t, rw.WriteFile("flow.json", []byte(`{"contracts": {"Foo": "Foo.cdc"}}`), 0644))
+		data, err := rw.ReadFile("flow.json")
+		require.NoError(t, err)
+		assert.Equal(t, "{\n\t\"contracts\": {\n\t\t\"Foo\": \"Foo.cdc\"\n\t},\n\t\"test\": {\n\t\t\"ignore\": [\n\t\t\t\"a\"\n\t\t]\n\t}\n}", string(data))
+
+		// files that aren't configuration files on the paths are written as they are
+		require.NoError(t, rw.WriteFile("Foo.cdc", []byte(`pub contract Foo {}`), 0644))
+		data, err = rw.ReadFile("Foo.cdc")
+		require.NoError(t, err)
+		assert.Equal(t, "pub contract Foo {}", string(data))
+	})
+}
@@ -0,0 +1,128 @@
+/*
+ * Flow CLI
+ *
+ * Copyright 2019 Dapper Labs, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	flowsdk "github.com/onflow/flow-go-sdk"
+
+	"github.com/onflow/flowkit/config"
+)
+
+// CoreContract is a standard contract already deployed at a well-known address on each network.
+type CoreContract struct {
+	Name      string
+	InfoLink  string
+	Addresses map[string]flowsdk.Address
+}
+
+func coreContract(name, infoLink, emulator, testnet, mainnet string) CoreContract {
+	return CoreContract{
+		Name:     name,
+		InfoLink: infoLink,
+		Addresses: map[string]flowsdk.Address{
+			config.EmulatorNetwork.Name: flowsdk.HexToAddress(emulator),
+			config.TestnetNetwork.Name:  flowsdk.HexToAddress(testnet),
+			config.MainnetNetwork.Name:  flowsdk.HexToAddress(mainnet),
+		},
+	}
+}
+
+// CoreContracts maps the contract name to the addresses of the core contracts on each network.
+var CoreContracts = map[string]CoreContract{
+	"FungibleToken": coreContract(
+		"FungibleToken",
+		"https://developers.flow.com/flow/core-contracts/fungible-token",
+		"0xee82856bf20e2aa6", "0x9a0766d93b6608b7", "0xf233dcee88fe0abe",
+	),
+	"FungibleTokenMetadataViews": coreContract(
+		"FungibleTokenMetadataViews",
+		"https://developers.flow.com/flow/core-contracts/fungible-token",
+		"0xee82856bf20e2aa6", "0x9a0766d93b6608b7", "0xf233dcee88fe0abe",
+	),
+	"FlowToken": coreContract(
+		"FlowToken",
+		"https://developers.flow.com/flow/core-contracts/flow-token",
+		"0x0ae53cb6e3f42a79", "0x7e60df042a9c0868", "0x1654653399040a61",
+	),
+	"FlowFees": coreContract(
+		"FlowFees",
+		"https://developers.flow.com/flow/core-contracts/flow-fees",
+		"0xe5a8b7f23e8b548f", "0x912d5440f7e3769e", "0xf919ee77447b7497",
+	),
+	"FlowServiceAccount": coreContract(
+		"FlowServiceAccount",
+		"https://developers.flow.com/flow/core-contracts/service-account",
+		"0xf8d6e0586b0a20c7", "0x8c5303eaa26202d6", "0xe467b9dd11fa00df",
+	),
+	"FlowStorageFees": coreContract(
+		"FlowStorageFees",
+		"https://developers.flow.com/flow/core-contracts/service-account",
+		"0xf8d6e0586b0a20c7", "0x8c5303eaa26202d6", "0xe467b9dd11fa00df",
+	),
+	"FlowIDTableStaking": coreContract(
+		"FlowIDTableStaking",
+		"https://developers.flow.com/flow/core-contracts/staking-contract-reference",
+		"0xf8d6e0586b0a20c7", "0x9eca2b38b18b5dfe", "0x8624b52f9ddcd04a",
+	),
+	"FlowEpoch": coreContract(
+		"FlowEpoch",
+		"https://developers.flow.com/flow/core-contracts/epoch-contract-reference",
+		"0xf8d6e0586b0a20c7", "0x9eca2b38b18b5dfe", "0x8624b52f9ddcd04a",
+	),
+	"FlowClusterQC": coreContract(
+		"FlowClusterQC",
+		"https://developers.flow.com/flow/core-contracts/epoch-contract-reference",
+		"0xf8d6e0586b0a20c7", "0x9eca2b38b18b5dfe", "0x8624b52f9ddcd04a",
+	),
+	"FlowDKG": coreContract(
+		"FlowDKG",
+		"https://developers.flow.com/flow/core-contracts/epoch-contract-reference",
+		"0xf8d6e0586b0a20c7", "0x9eca2b38b18b5dfe", "0x8624b52f9ddcd04a",
+	),
+	"NonFungibleToken": coreContract(
+		"NonFungibleToken",
+		"https://developers.flow.com/flow/core-contracts/non-fungible-token",
+		"0xf8d6e0586b0a20c7", "0x631e88ae7f1d7c20", "0x1d7e57aa55817448",
+	),
+	"MetadataViews": coreContract(
+		"MetadataViews",
+		"https://developers.flow.com/flow/core-contracts/nft-metadata",
+		"0xf8d6e0586b0a20c7", "0x631e88ae7f1d7c20", "0x1d7e57aa55817448",
+	),
+	"ViewResolver": coreContract(
+		"ViewResolver",
+		"https://developers.flow.com/flow/core-contracts/nft-metadata",
+		"0xf8d6e0586b0a20c7", "0x631e88ae7f1d7c20", "0x1d7e57aa55817448",
+	),
+}
+
+// CoreContractByAddress returns the core contract if the contract deployed at the address on the network is one.
+func CoreContractByAddress(name, network string, address flowsdk.Address) (CoreContract, bool) {
+	contract, ok := CoreContracts[name]
+	if !ok {
+		return CoreContract{}, false
+	}
+
+	coreAddress, ok := contract.Addresses[network]
+	if !ok || coreAddress != address {
+		return CoreContract{}, false
+	}
+
+	return contract, true
+}
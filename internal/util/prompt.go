@@ -29,7 +29,6 @@ import (
 	"github.com/manifoldco/promptui"
 	"github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/crypto"
-	"github.com/sergi/go-diff/diffmatchpatch"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 
@@ -252,10 +251,7 @@ func addAnotherContractToDeploymentPrompt() bool {
 // returns true if the user wishes to continue with the deployment and false otherwise
 func ShowContractDiffPrompt(logger output.Logger) func([]byte, []byte) bool {
 	return func(newContract []byte, existingContract []byte) bool {
-		dmp := diffmatchpatch.New()
-		diffs := dmp.DiffMain(string(newContract), string(existingContract), false)
-		diffString := dmp.DiffPrettyText(diffs)
-		logger.Info(diffString)
+		logger.Info(ContractDiff(newContract, existingContract))
 
 		deployPrompt := promptui.Prompt{
 			Label:     "Do you wish to deploy this contract?",
@@ -29,6 +29,7 @@ import (
 
 	flowsdk "github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/crypto"
+	"github.com/sergi/go-diff/diffmatchpatch"
 
 	"github.com/onflow/flowkit"
 )
@@ -86,6 +87,13 @@ func CreateTabWriter(b *bytes.Buffer) *tabwriter.Writer {
 	return tabwriter.NewWriter(b, 0, 8, 1, '\t', tabwriter.AlignRight)
 }
 
+// ContractDiff renders a colored diff between two versions of contract code.
+func ContractDiff(from []byte, to []byte) string {
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMain(string(from), string(to), false)
+	return dmp.DiffPrettyText(diffs)
+}
+
 // ValidateECDSAP256Pub attempt to decode the hex string representation of a ECDSA P256 public key
 func ValidateECDSAP256Pub(key string) error {
 	b, err := hex.DecodeString(strings.TrimPrefix(key, "0x"))